@@ -0,0 +1,125 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+)
+
+// AwayRule is defined by kasa devices; while it is active the device is switched on and off at random between the start and end times
+type AwayRule struct {
+	ID        string   `json:"id,omitempty"`
+	Name      string   `json:"name"`
+	Enable    uint     `json:"enable"`
+	Weekdays  Weekdays `json:"wday"`
+	Repeat    uint     `json:"repeat"` // 0 runs once on Year/Month/Day
+	StartOpt  TimeOpt  `json:"stime_opt"`
+	StartMin  int      `json:"smin"`
+	EndOpt    TimeOpt  `json:"etime_opt"`
+	EndMin    int      `json:"emin"`
+	Frequency int      `json:"frequency"` // how many times the device is switched on during the period
+	Duration  int      `json:"duration"`  // minutes each random on-period lasts
+	LastFor   int      `json:"lastfor"`   // minutes the away window keeps running
+	Year      int      `json:"year,omitempty"`
+	Month     int      `json:"month,omitempty"`
+	Day       int      `json:"day,omitempty"`
+}
+
+// Start returns the start time as "HH:MM", or an offset from sunrise/sunset
+func (r *AwayRule) Start() string {
+	return formatRuleTime(r.StartOpt, r.StartMin)
+}
+
+// End returns the end time as "HH:MM", or an offset from sunrise/sunset
+func (r *AwayRule) End() string {
+	return formatRuleTime(r.EndOpt, r.EndMin)
+}
+
+// maxAwayMinutes is a day, the longest any of the away rule periods can be
+const maxAwayMinutes = 24 * 60
+
+// Validate checks the fields the device would otherwise accept as 0 and then never switch anything, AddAwayRule and EditAwayRule call it
+func (r *AwayRule) Validate() error {
+	if r.Frequency < 1 {
+		return fmt.Errorf("away rule frequency must be at least 1, got %d", r.Frequency)
+	}
+	if r.Duration < 1 || r.Duration > maxAwayMinutes {
+		return fmt.Errorf("away rule duration must be 1-%d minutes, got %d", maxAwayMinutes, r.Duration)
+	}
+	if r.LastFor < 1 || r.LastFor > maxAwayMinutes {
+		return fmt.Errorf("away rule lastfor must be 1-%d minutes, got %d", maxAwayMinutes, r.LastFor)
+	}
+	return nil
+}
+
+// AwayRules is defined by kasa devices
+type AwayRules struct {
+	Rules   []AwayRule `json:"rule_list"`
+	Enable  uint       `json:"enable"`
+	Version int        `json:"version"`
+	KasaErr
+}
+
+// GetAwayRules returns the away mode (anti-theft) rules set up on a device
+func (d *Device) GetAwayRules() (*AwayRules, error) {
+	return d.GetAwayRulesCtx(context.Background())
+}
+
+func (d *Device) GetAwayRulesCtx(ctx context.Context) (*AwayRules, error) {
+	var rules AwayRules
+	if err := d.getRules(ctx, "anti_theft", &rules); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// AddAwayRule adds an away mode rule and returns the ID the device assigned to it
+func (d *Device) AddAwayRule(r AwayRule) (string, error) {
+	return d.AddAwayRuleCtx(context.Background(), r)
+}
+
+func (d *Device) AddAwayRuleCtx(ctx context.Context, r AwayRule) (string, error) {
+	if err := r.Validate(); err != nil {
+		return "", err
+	}
+	r.ID = ""
+	return d.addRule(ctx, "anti_theft", r)
+}
+
+// EditAwayRule replaces the away mode rule with the same ID
+func (d *Device) EditAwayRule(r AwayRule) error {
+	return d.EditAwayRuleCtx(context.Background(), r)
+}
+
+func (d *Device) EditAwayRuleCtx(ctx context.Context, r AwayRule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	return d.editRule(ctx, "anti_theft", r.ID, r)
+}
+
+// DeleteAwayRule removes one away mode rule by ID
+func (d *Device) DeleteAwayRule(id string) error {
+	return d.DeleteAwayRuleCtx(context.Background(), id)
+}
+
+func (d *Device) DeleteAwayRuleCtx(ctx context.Context, id string) error {
+	return d.deleteRule(ctx, "anti_theft", id)
+}
+
+// DeleteAllAwayRules removes every away mode rule from the device
+func (d *Device) DeleteAllAwayRules() error {
+	return d.DeleteAllAwayRulesCtx(context.Background())
+}
+
+func (d *Device) DeleteAllAwayRulesCtx(ctx context.Context) error {
+	return d.deleteAllRules(ctx, "anti_theft")
+}
+
+// EnableAwayRules turns away mode on or off without touching the individual rules
+func (d *Device) EnableAwayRules(enable bool) error {
+	return d.EnableAwayRulesCtx(context.Background(), enable)
+}
+
+func (d *Device) EnableAwayRulesCtx(ctx context.Context, enable bool) error {
+	return d.enableRules(ctx, "anti_theft", enable)
+}
@@ -0,0 +1,64 @@
+package kasa
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAwayRules(t *testing.T) {
+	var sent []string
+	d := ruleDevice("HS103(US)", `{"anti_theft":{"get_rules":{"rule_list":[{"id":"AW1","name":"vacation","enable":1,"wday":[1,1,1,1,1,1,1],"repeat":1,"stime_opt":2,"smin":0,"etime_opt":0,"emin":1410,"frequency":5,"duration":2,"lastfor":1}],"enable":1,"version":2,"err_code":0}}}`, &sent)
+
+	rules, err := d.GetAwayRulesCtx(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules.Rules) != 1 {
+		t.Fatalf("got %d rules", len(rules.Rules))
+	}
+	r := rules.Rules[0]
+	if r.Weekdays != Everyday || r.Start() != "sunset+0" || r.End() != "23:30" || r.Frequency != 5 {
+		t.Fatalf("got %+v", r)
+	}
+
+	sent = nil
+	d = ruleDevice("KL130(US)", `{"smartlife.iot.common.anti_theft":{"set_overall_enable":{"err_code":0}}}`, &sent)
+	if err := d.EnableAwayRulesCtx(context.Background(), true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sent) != 1 || !strings.Contains(sent[0], `{"smartlife.iot.common.anti_theft":{"set_overall_enable":{"enable":1}}}`) {
+		t.Fatalf("sent %v", sent)
+	}
+}
+
+func TestAddAwayRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    AwayRule
+		wantErr bool
+	}{
+		{"ok", AwayRule{Frequency: 5, Duration: 2, LastFor: 1}, false},
+		{"no frequency", AwayRule{Duration: 2, LastFor: 1}, true},
+		{"no duration", AwayRule{Frequency: 5, LastFor: 1}, true},
+		{"no lastfor", AwayRule{Frequency: 5, Duration: 2}, true},
+		{"duration over a day", AwayRule{Frequency: 5, Duration: 1441, LastFor: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			d := ruleDevice("HS103(US)", `{"anti_theft":{"add_rule":{"id":"AW2","err_code":0}}}`, &sent)
+			_, err := d.AddAwayRuleCtx(context.Background(), tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr && len(sent) != 0 {
+				t.Fatalf("invalid rule was sent: %v", sent)
+			}
+			if !tt.wantErr && (len(sent) != 1 || !strings.Contains(sent[0], `"duration":2,`) || !strings.Contains(sent[0], `"lastfor":1`)) {
+				t.Fatalf("sent %v", sent)
+			}
+		})
+	}
+}
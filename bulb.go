@@ -0,0 +1,167 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const lightingService = "smartlife.iot.smartbulb.lightingservice"
+
+// kelvinRanges are the color temperatures each tunable model accepts
+var kelvinRanges = map[string][2]int{
+	"KL120": {2700, 6500},
+	"KL125": {2500, 6500},
+	"KL130": {2500, 9000},
+	"KL135": {2500, 6500},
+	"KL430": {2500, 9000},
+	"LB120": {2700, 6500},
+	"LB130": {2500, 9000},
+	"LB230": {2500, 9000},
+}
+
+// ColorTempRange returns the lowest and highest color temperature in kelvin a model supports
+func ColorTempRange(model string) (int, int, bool) {
+	r, ok := kelvinRanges[baseModel(model)]
+	return r[0], r[1], ok
+}
+
+// IsOn reports if the bulb is lit
+func (l *LightState) IsOn() bool {
+	return l.OnOff == 1
+}
+
+// transitionLightState applies p over transition and returns the new state
+func (d *Device) transitionLightState(ctx context.Context, p Params, transition time.Duration) (*LightState, error) {
+	p["transition_period"] = int(transition / time.Millisecond)
+	p["ignore_default"] = 1
+
+	var l LightState
+	if err := d.call(ctx, NewRequest().Add(lightingService, "transition_light_state", p), &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// GetLightState returns the bulb's current light
+func (d *Device) GetLightState() (*LightState, error) {
+	return d.GetLightStateCtx(context.Background())
+}
+
+func (d *Device) GetLightStateCtx(ctx context.Context) (*LightState, error) {
+	var l LightState
+	if err := d.call(ctx, NewRequest().Add(lightingService, "get_light_state", nil), &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// SetLightOnOff turns a bulb on or off, fading over transition
+func (d *Device) SetLightOnOff(on bool, transition time.Duration) (*LightState, error) {
+	return d.SetLightOnOffCtx(context.Background(), on, transition)
+}
+
+func (d *Device) SetLightOnOffCtx(ctx context.Context, on bool, transition time.Duration) (*LightState, error) {
+	return d.transitionLightState(ctx, Params{"on_off": boolToInt(on)}, transition)
+}
+
+// SetLightBrightness sets a bulb's brightness, 1-100, turning it on
+func (d *Device) SetLightBrightness(brightness int, transition time.Duration) (*LightState, error) {
+	return d.SetLightBrightnessCtx(context.Background(), brightness, transition)
+}
+
+func (d *Device) SetLightBrightnessCtx(ctx context.Context, brightness int, transition time.Duration) (*LightState, error) {
+	if brightness < 1 || brightness > 100 {
+		return nil, fmt.Errorf("brightness must be 1-100, got %d", brightness)
+	}
+	return d.transitionLightState(ctx, Params{"on_off": 1, "brightness": brightness}, transition)
+}
+
+// SetLightHSV sets a color bulb's hue (0-360), saturation (0-100) and brightness (1-100)
+func (d *Device) SetLightHSV(hue, saturation, brightness int, transition time.Duration) (*LightState, error) {
+	return d.SetLightHSVCtx(context.Background(), hue, saturation, brightness, transition)
+}
+
+func (d *Device) SetLightHSVCtx(ctx context.Context, hue, saturation, brightness int, transition time.Duration) (*LightState, error) {
+	if hue < 0 || hue > 360 {
+		return nil, fmt.Errorf("hue must be 0-360, got %d", hue)
+	}
+	if saturation < 0 || saturation > 100 {
+		return nil, fmt.Errorf("saturation must be 0-100, got %d", saturation)
+	}
+	if brightness < 1 || brightness > 100 {
+		return nil, fmt.Errorf("brightness must be 1-100, got %d", brightness)
+	}
+
+	c, err := d.CapabilitiesCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Has(CapColor) {
+		return nil, fmt.Errorf("device does not support %s", CapColor)
+	}
+
+	return d.transitionLightState(ctx, Params{
+		"on_off":     1,
+		"hue":        hue,
+		"saturation": saturation,
+		"brightness": brightness,
+		"color_temp": 0, // a non-zero color_temp overrides hue and saturation
+	}, transition)
+}
+
+// SetLightColorTemp sets a tunable bulb's white color temperature in kelvin, within the model's range
+func (d *Device) SetLightColorTemp(kelvin int, transition time.Duration) (*LightState, error) {
+	return d.SetLightColorTempCtx(context.Background(), kelvin, transition)
+}
+
+func (d *Device) SetLightColorTempCtx(ctx context.Context, kelvin int, transition time.Duration) (*LightState, error) {
+	s, err := d.GetSettingsCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !s.Capabilities().Has(CapColorTemp) {
+		return nil, fmt.Errorf("%s does not support %s", s.Model, CapColorTemp)
+	}
+	if lo, hi, ok := ColorTempRange(s.Model); ok && (kelvin < lo || kelvin > hi) {
+		return nil, fmt.Errorf("%s supports %dK-%dK, got %dK", s.Model, lo, hi, kelvin)
+	}
+
+	return d.transitionLightState(ctx, Params{"on_off": 1, "color_temp": kelvin}, transition)
+}
+
+// ApplyPreset sets the bulb to a preset, such as one of Sysinfo.PreferredState
+func (d *Device) ApplyPreset(p Preset, transition time.Duration) (*LightState, error) {
+	return d.ApplyPresetCtx(context.Background(), p, transition)
+}
+
+func (d *Device) ApplyPresetCtx(ctx context.Context, p Preset, transition time.Duration) (*LightState, error) {
+	params := Params{
+		"on_off":     1,
+		"brightness": p.Brightness,
+		"color_temp": p.ColorTemp,
+	}
+	if p.ColorTemp == 0 {
+		params["hue"] = p.Hue
+		params["saturation"] = p.Saturation
+	}
+	return d.transitionLightState(ctx, params, transition)
+}
+
+// ApplyPresetIndex sets the bulb to the preset saved in the app at index
+func (d *Device) ApplyPresetIndex(index uint, transition time.Duration) (*LightState, error) {
+	return d.ApplyPresetIndexCtx(context.Background(), index, transition)
+}
+
+func (d *Device) ApplyPresetIndexCtx(ctx context.Context, index uint, transition time.Duration) (*LightState, error) {
+	s, err := d.GetSettingsCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range s.PreferredState {
+		if p.Index == index {
+			return d.ApplyPresetCtx(ctx, p, transition)
+		}
+	}
+	return nil, fmt.Errorf("no preset %d, the device has %d", index, len(s.PreferredState))
+}
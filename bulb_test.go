@@ -0,0 +1,100 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBulbTransitions(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     string
+		call      func(d *Device) (*LightState, error)
+		want      string
+		shouldErr bool
+	}{
+		{"off", "KL110(US)", func(d *Device) (*LightState, error) {
+			return d.SetLightOnOffCtx(context.Background(), false, time.Second)
+		},
+			`{"smartlife.iot.smartbulb.lightingservice":{"transition_light_state":{"ignore_default":1,"on_off":0,"transition_period":1000}}}`, false},
+		{"brightness", "KL110(US)", func(d *Device) (*LightState, error) { return d.SetLightBrightnessCtx(context.Background(), 30, 0) },
+			`{"smartlife.iot.smartbulb.lightingservice":{"transition_light_state":{"brightness":30,"ignore_default":1,"on_off":1,"transition_period":0}}}`, false},
+		{"brightness range", "KL110(US)", func(d *Device) (*LightState, error) { return d.SetLightBrightnessCtx(context.Background(), 0, 0) }, "", true},
+		{"hsv", "KL130(US)", func(d *Device) (*LightState, error) { return d.SetLightHSVCtx(context.Background(), 120, 100, 75, 0) },
+			`{"smartlife.iot.smartbulb.lightingservice":{"transition_light_state":{"brightness":75,"color_temp":0,"hue":120,"ignore_default":1,"on_off":1,"saturation":100,"transition_period":0}}}`, false},
+		{"hsv on white bulb", "KL120(US)", func(d *Device) (*LightState, error) { return d.SetLightHSVCtx(context.Background(), 120, 100, 75, 0) }, "", true},
+		{"color temp", "KL125(US)", func(d *Device) (*LightState, error) { return d.SetLightColorTempCtx(context.Background(), 4000, 0) },
+			`{"smartlife.iot.smartbulb.lightingservice":{"transition_light_state":{"color_temp":4000,"ignore_default":1,"on_off":1,"transition_period":0}}}`, false},
+		{"color temp range", "KL125(US)", func(d *Device) (*LightState, error) { return d.SetLightColorTempCtx(context.Background(), 9000, 0) }, "", true},
+		{"preset", "KL130(US)", func(d *Device) (*LightState, error) { return d.ApplyPresetIndexCtx(context.Background(), 1, 0) },
+			`{"smartlife.iot.smartbulb.lightingservice":{"transition_light_state":{"brightness":80,"color_temp":0,"hue":240,"ignore_default":1,"on_off":1,"saturation":100,"transition_period":0}}}`, false},
+		{"missing preset", "KL130(US)", func(d *Device) (*LightState, error) { return d.ApplyPresetIndexCtx(context.Background(), 5, 0) }, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			sysinfo := `{"model":"` + tt.model + `","mic_type":"IOT.SMARTBULB","preferred_state":[{"index":0,"hue":0,"saturation":0,"color_temp":2700,"brightness":50},{"index":1,"hue":240,"saturation":100,"color_temp":0,"brightness":80}],"err_code":0}`
+			d := sysinfoDevice(sysinfo, `{"smartlife.iot.smartbulb.lightingservice":{"transition_light_state":{"on_off":1,"mode":"normal","hue":0,"saturation":0,"color_temp":2700,"brightness":50,"err_code":0}}}`, &sent)
+
+			l, err := tt.call(d)
+			if tt.shouldErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if len(sent) != 0 {
+					t.Fatalf("sent %v after a failed check", sent)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !l.IsOn() || l.ColorTemp != 2700 {
+				t.Fatalf("got state %+v", l)
+			}
+			if sent[0] != tt.want {
+				t.Fatalf("sent %s, want %s", sent[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestSysinfoViews(t *testing.T) {
+	tests := []struct {
+		name    string
+		sysinfo string
+		on      bool
+		level   uint
+		mac     string
+		caps    Capabilities
+	}{
+		{"plug", `{"model":"HS103(US)","mic_type":"IOT.SMARTPLUGSWITCH","relay_state":1,"mac":"50:C7:BF:00:11:22"}`, true, 100, "50:C7:BF:00:11:22", CapRelay},
+		{"dimmer", `{"model":"HS220(US)","mic_type":"IOT.SMARTPLUGSWITCH","relay_state":0,"brightness":40,"mac":"50:C7:BF:00:11:23"}`, false, 40, "50:C7:BF:00:11:23", CapRelay | CapDimmer},
+		{"bulb on", `{"model":"KL130(US)","mic_type":"IOT.SMARTBULB","mic_mac":"1c3bf3aabbcc","is_dimmable":1,"is_color":1,"is_variable_color_temp":1,"light_state":{"on_off":1,"mode":"normal","hue":120,"saturation":100,"color_temp":0,"brightness":65}}`, true, 65, "1C:3B:F3:AA:BB:CC", CapBulb | CapDimmable | CapColor | CapColorTemp | CapEmeter},
+		{"bulb off", `{"model":"KL999(US)","mic_type":"IOT.SMARTBULB","mic_mac":"1C3BF3AABBCD","is_dimmable":1,"is_color":0,"is_variable_color_temp":1,"light_state":{"on_off":0,"dft_on_state":{"mode":"normal","hue":0,"saturation":0,"color_temp":2700,"brightness":20}}}`, false, 20, "1C:3B:F3:AA:BB:CD", CapBulb | CapDimmable | CapColorTemp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Sysinfo
+			if err := json.Unmarshal([]byte(tt.sysinfo), &s); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.IsOn() != tt.on || s.Level() != tt.level || s.MACAddress() != tt.mac {
+				t.Fatalf("got on %t level %d mac %s", s.IsOn(), s.Level(), s.MACAddress())
+			}
+			if c := s.Capabilities(); c != tt.caps {
+				t.Fatalf("got capabilities %s, want %s", c, tt.caps)
+			}
+
+			d := &Device{MAC: strings.ToLower(tt.mac)}
+			if !d.matches(&s) {
+				t.Fatalf("%s does not match %s", d.MAC, s.MACAddress())
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Capabilities is a set of features a device supports
+type Capabilities uint32
+
+const (
+	CapRelay       Capabilities = 1 << iota // on/off relay (plugs, switches)
+	CapDimmer                               // smartlife.iot.dimmer wall dimmers
+	CapEmeter                               // energy monitoring
+	CapChildren                             // multiple outlets addressed by child ID
+	CapLightSensor                          // smartlife.iot.LAS ambient light sensor
+	CapMotion                               // smartlife.iot.PIR motion sensor
+	CapBulb                                 // smartlife.iot.smartbulb.lightingservice
+	CapColor                                // bulb hue and saturation
+	CapColorTemp                            // bulb variable color temperature
+	CapLightStrip                           // smartlife.iot.lightStrip zones and effects
+	CapDimmable                             // bulb brightness through the lighting service
+)
+
+var capNames = []struct {
+	c    Capabilities
+	name string
+}{
+	{CapRelay, "relay"},
+	{CapDimmer, "dimmer"},
+	{CapEmeter, "emeter"},
+	{CapChildren, "children"},
+	{CapLightSensor, "lightsensor"},
+	{CapMotion, "motion"},
+	{CapBulb, "bulb"},
+	{CapColor, "color"},
+	{CapColorTemp, "colortemp"},
+	{CapLightStrip, "lightstrip"},
+	{CapDimmable, "dimmable"},
+}
+
+// Has reports if every capability in f is present
+func (c Capabilities) Has(f Capabilities) bool {
+	return c&f == f
+}
+
+func (c Capabilities) String() string {
+	var names []string
+	for _, n := range capNames {
+		if c.Has(n.c) {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// MarshalText lets JSON output show the capability names
+func (c Capabilities) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// ModelInfo describes a known model
+type ModelInfo struct {
+	Description  string
+	Capabilities Capabilities
+}
+
+// Models is the registry of known models, keyed without the region suffix, e.g. "HS220" not "HS220(US)"
+var Models = map[string]ModelInfo{
+	"HS100":   {"Smart Wi-Fi Plug", CapRelay},
+	"HS103":   {"Smart Wi-Fi Plug Lite", CapRelay},
+	"HS105":   {"Smart Wi-Fi Plug Mini", CapRelay},
+	"HS110":   {"Smart Wi-Fi Plug with Energy Monitoring", CapRelay | CapEmeter},
+	"HS200":   {"Smart Wi-Fi Light Switch", CapRelay},
+	"HS210":   {"Smart Wi-Fi 3-Way Light Switch", CapRelay},
+	"HS220":   {"Smart Wi-Fi Light Switch, Dimmer", CapRelay | CapDimmer},
+	"HS300":   {"Smart Wi-Fi Power Strip", CapRelay | CapChildren | CapEmeter},
+	"KP115":   {"Smart Wi-Fi Plug Slim with Energy Monitoring", CapRelay | CapEmeter},
+	"KP303":   {"Smart Wi-Fi Power Strip", CapRelay | CapChildren},
+	"KP400":   {"Smart Outdoor Plug", CapRelay | CapChildren},
+	"KS230":   {"Smart Wi-Fi 3-Way Dimmer Switch Kit", CapRelay | CapDimmer},
+	"KS200M":  {"Smart Wi-Fi Light Switch, Motion-Activated", CapRelay | CapMotion | CapLightSensor},
+	"ES20M":   {"Smart Wi-Fi Dimmer Switch, Motion-Activated", CapRelay | CapDimmer | CapMotion | CapLightSensor},
+	"KL50":    {"Smart Wi-Fi Filament Bulb", CapBulb | CapDimmable | CapEmeter},
+	"KL110":   {"Smart Wi-Fi LED Bulb, Dimmable White", CapBulb | CapDimmable | CapEmeter},
+	"KL120":   {"Smart Wi-Fi LED Bulb, Tunable White", CapBulb | CapDimmable | CapColorTemp | CapEmeter},
+	"KL125":   {"Smart Wi-Fi LED Bulb, Multicolor", CapBulb | CapDimmable | CapColor | CapColorTemp | CapEmeter},
+	"KL130":   {"Smart Wi-Fi LED Bulb, Multicolor", CapBulb | CapDimmable | CapColor | CapColorTemp | CapEmeter},
+	"KL135":   {"Smart Wi-Fi LED Bulb, Multicolor", CapBulb | CapDimmable | CapColor | CapColorTemp | CapEmeter},
+	"KL400L5": {"Smart Wi-Fi LED Light Strip", CapBulb | CapDimmable | CapColor | CapLightStrip | CapEmeter},
+	"KL420L5": {"Smart Wi-Fi LED Light Strip", CapBulb | CapDimmable | CapColor | CapLightStrip | CapEmeter},
+	"KL430":   {"Smart Wi-Fi LED Light Strip, Multicolor", CapBulb | CapDimmable | CapColor | CapColorTemp | CapLightStrip | CapEmeter},
+	"LB100":   {"Smart Wi-Fi LED Bulb, Dimmable White", CapBulb | CapDimmable},
+	"LB110":   {"Smart Wi-Fi LED Bulb, Dimmable White", CapBulb | CapDimmable},
+	"LB120":   {"Smart Wi-Fi LED Bulb, Tunable White", CapBulb | CapDimmable | CapColorTemp},
+	"LB130":   {"Smart Wi-Fi LED Bulb, Multicolor", CapBulb | CapDimmable | CapColor | CapColorTemp},
+	"LB230":   {"Smart Wi-Fi LED Bulb, Multicolor", CapBulb | CapDimmable | CapColor | CapColorTemp},
+}
+
+// baseModel strips the region and version, "HS220(US)" becomes "HS220"
+func baseModel(model string) string {
+	if i := strings.IndexAny(model, "( "); i >= 0 {
+		model = model[:i]
+	}
+	return strings.ToUpper(model)
+}
+
+// LookupModel returns the registry entry for a model as reported in Sysinfo.Model
+func LookupModel(model string) (ModelInfo, bool) {
+	m, ok := Models[baseModel(model)]
+	return m, ok
+}
+
+// Capabilities derives what the device supports from its sysinfo and the model registry
+func (s *Sysinfo) Capabilities() Capabilities {
+	var c Capabilities
+	if m, ok := LookupModel(s.Model); ok {
+		c = m.Capabilities
+	}
+
+	// "TIM:ENE" means timers and energy monitoring
+	for _, f := range strings.Split(s.Feature, ":") {
+		if f == "ENE" {
+			c |= CapEmeter
+		}
+	}
+
+	// bulbs describe themselves
+	if s.IsDimmable == 1 {
+		c |= CapDimmable
+	}
+	if s.IsColor == 1 {
+		c |= CapColor
+	}
+	if s.IsVariableColorTemp == 1 {
+		c |= CapColorTemp
+	}
+
+	switch s.MIC {
+	case "IOT.SMARTPLUGSWITCH":
+		c |= CapRelay
+	case "IOT.SMARTBULB":
+		c |= CapBulb
+	}
+
+	if s.NumChildren > 0 || len(s.Children) > 0 {
+		c |= CapChildren
+	}
+	return c
+}
+
+// Capabilities returns what the device supports based on its sysinfo, the result is cached.
+// Plugs and switches missing from the registry are probed, since sysinfo alone does not show a dimmer.
+func (d *Device) Capabilities() (Capabilities, error) {
+	return d.CapabilitiesCtx(context.Background())
+}
+
+func (d *Device) CapabilitiesCtx(ctx context.Context) (Capabilities, error) {
+	d.mu.Lock()
+	c, known := d.caps, d.capsKnown
+	d.mu.Unlock()
+	if known {
+		return c, nil
+	}
+
+	s, err := d.GetSettingsCtx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := LookupModel(s.Model); !ok && s.MIC != "IOT.SMARTBULB" {
+		return d.ProbeCapabilitiesCtx(ctx)
+	}
+	c = s.Capabilities()
+
+	d.mu.Lock()
+	d.caps, d.capsKnown = c, true
+	d.mu.Unlock()
+	return c, nil
+}
+
+// probes are the optional modules ProbeCapabilities asks about
+var probes = []struct {
+	module string
+	method string
+	c      Capabilities
+}{
+	{"smartlife.iot.dimmer", "get_dimmer_parameters", CapDimmer},
+	{"emeter", "get_realtime", CapEmeter},
+	{"smartlife.iot.LAS", "get_config", CapLightSensor},
+	{"smartlife.iot.PIR", "get_config", CapMotion},
+}
+
+// ProbeCapabilities asks the device directly which optional modules it has, for models not in the registry.
+// Everything is asked in one round trip; the result replaces the cached capabilities.
+func (d *Device) ProbeCapabilities() (Capabilities, error) {
+	return d.ProbeCapabilitiesCtx(context.Background())
+}
+
+func (d *Device) ProbeCapabilitiesCtx(ctx context.Context) (Capabilities, error) {
+	r := NewRequest().Add("system", "get_sysinfo", nil)
+	for _, p := range probes {
+		r.Add(p.module, p.method, nil)
+	}
+
+	q, err := d.QueryCtx(ctx, r)
+	if err != nil {
+		return 0, err
+	}
+	if err := q.Err("system", "get_sysinfo"); err != nil {
+		return 0, err
+	}
+
+	c := q.GetSysinfo.Sysinfo.Capabilities()
+	for _, p := range probes {
+		err := q.Err(p.module, p.method)
+		switch {
+		case err == nil:
+			c |= p.c
+		case errors.Is(err, ErrModuleNotSupported), errors.Is(err, ErrMethodNotSupported):
+			c &^= p.c
+		}
+	}
+
+	d.mu.Lock()
+	d.caps, d.capsKnown = c, true
+	d.mu.Unlock()
+	return c, nil
+}
+
+// commonModule picks the namespace for the rule modules: plugs use name, dimmers and bulbs use smartlife.iot.common.name
+func (d *Device) commonModule(ctx context.Context, name string) (string, error) {
+	c, err := d.CapabilitiesCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+	if c.Has(CapDimmer) || c.Has(CapBulb) {
+		return "smartlife.iot.common." + name, nil
+	}
+	return name, nil
+}
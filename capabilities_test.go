@@ -0,0 +1,91 @@
+package kasa
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSysinfoCapabilities(t *testing.T) {
+	tests := []struct {
+		name string
+		s    Sysinfo
+		want Capabilities
+	}{
+		{"HS103", Sysinfo{Model: "HS103(US)", MIC: "IOT.SMARTPLUGSWITCH", Feature: "TIM"}, CapRelay},
+		{"HS110", Sysinfo{Model: "HS110(EU)", MIC: "IOT.SMARTPLUGSWITCH", Feature: "TIM:ENE"}, CapRelay | CapEmeter},
+		{"HS220", Sysinfo{Model: "HS220(US)", MIC: "IOT.SMARTPLUGSWITCH", Feature: "TIM"}, CapRelay | CapDimmer},
+		{"HS300", Sysinfo{Model: "HS300(US)", MIC: "IOT.SMARTPLUGSWITCH", Feature: "TIM:ENE", NumChildren: 6}, CapRelay | CapChildren | CapEmeter},
+		{"unknown plug with emeter", Sysinfo{Model: "XX999(US)", MIC: "IOT.SMARTPLUGSWITCH", Feature: "TIM:ENE"}, CapRelay | CapEmeter},
+		{"KL130", Sysinfo{Model: "KL130(US)", MIC: "IOT.SMARTBULB"}, CapBulb | CapDimmable | CapColor | CapColorTemp | CapEmeter},
+		{"unknown bulb", Sysinfo{Model: "KL999(US)", MIC: "IOT.SMARTBULB"}, CapBulb},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Capabilities(); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	d := mockQuery(`{
+		"system":{"get_sysinfo":{"model":"XX999(US)","mic_type":"IOT.SMARTPLUGSWITCH","feature":"TIM","err_code":0}},
+		"smartlife.iot.dimmer":{"get_dimmer_parameters":{"minThreshold":5,"err_code":0}},
+		"emeter":{"err_code":-1,"err_msg":"module not support"},
+		"smartlife.iot.LAS":{"err_code":-1,"err_msg":"module not support"},
+		"smartlife.iot.PIR":{"err_code":-1,"err_msg":"module not support"}
+	}`)
+
+	c, err := d.ProbeCapabilitiesCtx(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := CapRelay | CapDimmer; c != want {
+		t.Fatalf("got %s, want %s", c, want)
+	}
+
+	// cached, the transport isn't asked again
+	d.Transport = nil
+	if c, err = d.CapabilitiesCtx(context.Background()); err != nil || c != CapRelay|CapDimmer {
+		t.Fatalf("got %s %v from cache", c, err)
+	}
+}
+
+func TestUnlistedDimmer(t *testing.T) {
+	sysinfo := `"system":{"get_sysinfo":{"model":"KS220(US)","mic_type":"IOT.SMARTPLUGSWITCH","feature":"TIM","err_code":0}}`
+	probes := 0
+	d := &Device{
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				if !strings.Contains(cmd, "get_dimmer_parameters") {
+					return []byte(`{` + sysinfo + `}`), nil
+				}
+				probes++
+				return []byte(`{` + sysinfo + `,
+					"smartlife.iot.dimmer":{"get_dimmer_parameters":{"minThreshold":5,"err_code":0}},
+					"emeter":{"err_code":-1,"err_msg":"module not support"},
+					"smartlife.iot.LAS":{"err_code":-1,"err_msg":"module not support"},
+					"smartlife.iot.PIR":{"err_code":-1,"err_msg":"module not support"}}`), nil
+			},
+		},
+	}
+
+	c, err := d.CapabilitiesCtx(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := CapRelay | CapDimmer; c != want {
+		t.Fatalf("got %s, want %s", c, want)
+	}
+
+	m, err := d.commonModule(context.Background(), "schedule")
+	if err != nil || m != "smartlife.iot.common.schedule" {
+		t.Fatalf("got module %q %v", m, err)
+	}
+	if probes != 1 {
+		t.Fatalf("probed %d times, want 1", probes)
+	}
+}
@@ -23,7 +23,7 @@ func queryall(ctx context.Context, results chan emeterdata) error {
 	var wg sync.WaitGroup
 
 	for k, v := range m {
-		kd, err := kasa.NewDevice(k)
+		kd, err := kasa.NewDevice(k, kasa.WithDialTimeout(timeout), kasa.WithReadTimeout(timeout))
 		if err != nil {
 			return err
 		}
@@ -42,12 +42,11 @@ var startup = &cli.Command{
 			case <-ticker.C:
 				// drop any lingering attempts before the next tick
 				runCtx, cancel := context.WithTimeout(ctx, 25*time.Second)
-				defer cancel()
 				if err := queryall(runCtx, results); err != nil {
 					emlog.Error("query error", "err", err)
 				}
+				cancel()
 			}
 		}
-		return nil
 	},
 }
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/cloudkucooland/go-kasa"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/sync/errgroup"
+)
+
+var away = &cli.Command{
+	Name:  "away",
+	Usage: "manage away mode (randomized on/off while you are gone)",
+	Commands: []*cli.Command{
+		{
+			Name:      "list",
+			Usage:     "list the away mode rules",
+			ArgsUsage: "host",
+			Before:    RequireDevice,
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				res, err := k.GetAwayRulesCtx(ctx)
+				if err != nil {
+					return err
+				}
+
+				return formatOutput(cmd, res, func() {
+					tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					if !cmd.Bool("no-header") {
+						fmt.Fprintf(tabwrite, "Away mode enabled: %d\n", res.Enable)
+						fmt.Fprintf(tabwrite, "ID\tName\tEnable\tDays\tStart\tEnd\tFrequency\tDuration\tLast For\n")
+					}
+					for _, r := range res.Rules {
+						fmt.Fprintf(tabwrite, "%s\t%s\t%d\t%s\t%s\t%s\t%d\t%dm\t%dm\n", r.ID, r.Name, r.Enable, r.Weekdays, r.Start(), r.End(), r.Frequency, r.Duration, r.LastFor)
+					}
+					_ = tabwrite.Flush()
+				})
+			},
+		},
+		{
+			Name:      "set",
+			Usage:     "replace the away mode rules on every host with one rule and turn away mode on",
+			UsageText: "kasa away set --at sunset --until 23:30 host [host...]",
+			ArgsUsage: "host [host...]",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Value: "vacation"},
+				&cli.StringFlag{Name: "days", Usage: "mon,tue,... weekend, workdays or everyday", Value: "everyday"},
+				&cli.StringFlag{Name: "at", Usage: "start time: HH:MM, sunrise[+-min] or sunset[+-min]", Value: "sunset"},
+				&cli.StringFlag{Name: "until", Usage: "end time", Value: "23:30"},
+				&cli.IntFlag{Name: "frequency", Usage: "times each light is switched on during the period", Value: 5},
+				&cli.IntFlag{Name: "duration", Usage: "minutes each random on-period lasts", Value: 2},
+				&cli.IntFlag{Name: "lastfor", Usage: "minutes the away window keeps running", Value: 1},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				w, err := kasa.ParseWeekdays(cmd.String("days"))
+				if err != nil {
+					return err
+				}
+				sopt, smin, err := kasa.ParseRuleTime(cmd.String("at"))
+				if err != nil {
+					return err
+				}
+				eopt, emin, err := kasa.ParseRuleTime(cmd.String("until"))
+				if err != nil {
+					return err
+				}
+				r := kasa.AwayRule{
+					Name:      cmd.String("name"),
+					Enable:    1,
+					Weekdays:  w,
+					Repeat:    1,
+					StartOpt:  sopt,
+					StartMin:  smin,
+					EndOpt:    eopt,
+					EndMin:    emin,
+					Frequency: int(cmd.Int("frequency")),
+					Duration:  int(cmd.Int("duration")),
+					LastFor:   int(cmd.Int("lastfor")),
+				}
+				// check before the existing rules are deleted
+				if err := r.Validate(); err != nil {
+					return err
+				}
+
+				return eachHost(ctx, cmd, func(ctx context.Context, k *kasa.Device) error {
+					if err := k.DeleteAllAwayRulesCtx(ctx); err != nil {
+						return err
+					}
+					if _, err := k.AddAwayRuleCtx(ctx, r); err != nil {
+						return err
+					}
+					return k.EnableAwayRulesCtx(ctx, true)
+				})
+			},
+		},
+		{
+			Name:      "off",
+			Usage:     "turn away mode off on every host, the rules are kept",
+			ArgsUsage: "host [host...]",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				return eachHost(ctx, cmd, func(ctx context.Context, k *kasa.Device) error {
+					return k.EnableAwayRulesCtx(ctx, false)
+				})
+			},
+		},
+		{
+			Name:      "on",
+			Usage:     "turn away mode back on using the existing rules",
+			ArgsUsage: "host [host...]",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				return eachHost(ctx, cmd, func(ctx context.Context, k *kasa.Device) error {
+					return k.EnableAwayRulesCtx(ctx, true)
+				})
+			},
+		},
+		{
+			Name:      "delete",
+			Usage:     "delete an away mode rule",
+			ArgsUsage: "host id",
+			Before:    RequireDevice,
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "id"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				return k.DeleteAwayRuleCtx(ctx, cmd.StringArg("id"))
+			},
+		},
+		{
+			Name:      "clear",
+			Usage:     "delete every away mode rule",
+			ArgsUsage: "host [host...]",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				return eachHost(ctx, cmd, func(ctx context.Context, k *kasa.Device) error {
+					return k.DeleteAllAwayRulesCtx(ctx)
+				})
+			},
+		},
+	},
+}
+
+// eachHost runs fn against every host argument
+func eachHost(ctx context.Context, cmd *cli.Command, fn func(context.Context, *kasa.Device) error) error {
+	hosts := cmd.Args().Slice()
+	if len(hosts) == 0 {
+		return fmt.Errorf("at least one host is required")
+	}
+	return eachOf(ctx, cmd, hosts, fn)
+}
+
+// eachOf runs fn against every host in parallel, reporting failures per host
+func eachOf(ctx context.Context, cmd *cli.Command, hosts []string, fn func(context.Context, *kasa.Device) error) error {
+	var g errgroup.Group
+	errs := make([]error, len(hosts))
+	for i, host := range hosts {
+		g.Go(func() error {
+			k, err := newDevice(ctx, cmd, host)
+			if err == nil {
+				err = fn(ctx, k)
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", host, err)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d hosts failed", failed, len(hosts))
+	}
+	return nil
+}
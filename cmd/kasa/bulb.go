@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudkucooland/go-kasa"
+	"github.com/urfave/cli/v3"
+)
+
+// transitionFlag is a func so each command gets its own flag instance
+func transitionFlag() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{Name: "transition", Usage: "fade time in milliseconds"},
+	}
+}
+
+func transition(cmd *cli.Command) time.Duration {
+	return time.Duration(cmd.Int("transition")) * time.Millisecond
+}
+
+var bulb = &cli.Command{
+	Name:  "bulb",
+	Usage: "smart bulb controls (KL and LB series)",
+	Commands: []*cli.Command{
+		{
+			Name:      "show",
+			Usage:     "show the current light and saved presets",
+			ArgsUsage: "host",
+			Before:    RequireCapability(kasa.CapBulb),
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				l, err := k.GetLightStateCtx(ctx)
+				if err != nil {
+					return err
+				}
+				s, err := k.GetSettingsCtx(ctx)
+				if err != nil {
+					return err
+				}
+
+				return formatOutput(cmd, l, func() {
+					tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+					fmt.Fprintf(tabwrite, "State:\t%s\n", i2o(uint(l.OnOff)))
+					if !l.IsOn() && l.DefaultOn != nil {
+						fmt.Fprintf(tabwrite, "Comes on as:\t%s\n", presetString(*l.DefaultOn))
+					} else {
+						fmt.Fprintf(tabwrite, "Light:\t%s\n", lightString(l))
+					}
+					if lo, hi, ok := kasa.ColorTempRange(s.Model); ok {
+						fmt.Fprintf(tabwrite, "Color Temp Range:\t%dK-%dK\n", lo, hi)
+					}
+					for _, p := range s.PreferredState {
+						fmt.Fprintf(tabwrite, "Preset %d:\t%s\n", p.Index, presetString(p))
+					}
+					_ = tabwrite.Flush()
+				})
+			},
+		},
+		{
+			Name:      "on",
+			Usage:     "turn the bulb on",
+			ArgsUsage: "host",
+			Before:    RequireCapability(kasa.CapBulb),
+			Flags:     transitionFlag(),
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				_, err := k.SetLightOnOffCtx(ctx, true, transition(cmd))
+				return err
+			},
+		},
+		{
+			Name:      "off",
+			Usage:     "turn the bulb off",
+			ArgsUsage: "host",
+			Before:    RequireCapability(kasa.CapBulb),
+			Flags:     transitionFlag(),
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				_, err := k.SetLightOnOffCtx(ctx, false, transition(cmd))
+				return err
+			},
+		},
+		{
+			Name:      "brightness",
+			Usage:     "set the brightness (1-100)",
+			ArgsUsage: "host brightness",
+			Before:    RequireCapability(kasa.CapBulb),
+			Flags:     transitionFlag(),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.IntArg{Name: "brightness"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				_, err := k.SetLightBrightnessCtx(ctx, int(cmd.IntArg("brightness")), transition(cmd))
+				return err
+			},
+		},
+		{
+			Name:      "hsv",
+			Usage:     "set the color: hue (0-360), saturation (0-100), brightness (1-100)",
+			ArgsUsage: "host hue saturation brightness",
+			Before:    RequireCapability(kasa.CapBulb | kasa.CapColor),
+			Flags:     transitionFlag(),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.IntArg{Name: "hue"},
+				&cli.IntArg{Name: "saturation"},
+				&cli.IntArg{Name: "brightness"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				_, err := k.SetLightHSVCtx(ctx, int(cmd.IntArg("hue")), int(cmd.IntArg("saturation")), int(cmd.IntArg("brightness")), transition(cmd))
+				return err
+			},
+		},
+		{
+			Name:      "temp",
+			Usage:     "set the white color temperature in kelvin",
+			ArgsUsage: "host kelvin",
+			Before:    RequireCapability(kasa.CapBulb | kasa.CapColorTemp),
+			Flags:     transitionFlag(),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.IntArg{Name: "kelvin"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				_, err := k.SetLightColorTempCtx(ctx, int(cmd.IntArg("kelvin")), transition(cmd))
+				return err
+			},
+		},
+		{
+			Name:      "preset",
+			Usage:     "apply a preset saved in the app",
+			ArgsUsage: "host index",
+			Before:    RequireCapability(kasa.CapBulb),
+			Flags:     transitionFlag(),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "index"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				i, err := strconv.ParseUint(cmd.StringArg("index"), 10, 32)
+				if err != nil {
+					return fmt.Errorf("invalid preset index %q, see kasa bulb show", cmd.StringArg("index"))
+				}
+				_, err = k.ApplyPresetIndexCtx(ctx, uint(i), transition(cmd))
+				return err
+			},
+		},
+	},
+}
+
+func presetString(p kasa.Preset) string {
+	if p.ColorTemp > 0 {
+		return fmt.Sprintf("%dK %d%%", p.ColorTemp, p.Brightness)
+	}
+	return fmt.Sprintf("hue %d sat %d%% %d%%", p.Hue, p.Saturation, p.Brightness)
+}
+
+func lightString(l *kasa.LightState) string {
+	return presetString(kasa.Preset{Hue: l.Hue, Saturation: l.Saturation, ColorTemp: l.ColorTemp, Brightness: uint(l.Brightness)})
+}
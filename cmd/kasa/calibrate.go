@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudkucooland/go-kasa"
+	"github.com/urfave/cli/v3"
+)
+
+var calibrate = &cli.Command{
+	Name:  "calibrate",
+	Usage: "calibrate the energy meter against a reference meter",
+	UsageText: `kasa calibrate --volts 120.2 --amps 1.31 host
+
+   Plug a steady resistive load (a heater or incandescent lamp) into the device,
+   read the voltage and current from a reference meter, and pass them in.
+   The device is sampled, new gains are computed and applied, then the result is checked.`,
+	ArgsUsage: "host",
+	Before:    RequireCapability(kasa.CapEmeter),
+	Flags: []cli.Flag{
+		&cli.FloatFlag{Name: "volts", Usage: "reference voltage (V)"},
+		&cli.FloatFlag{Name: "amps", Usage: "reference current (A), leave out to only calibrate voltage"},
+		&cli.IntFlag{Name: "samples", Usage: "readings to average", Value: 3},
+		&cli.BoolFlag{Name: "auto", Usage: "let the device calibrate itself against the reference (start_calibration)"},
+		&cli.BoolFlag{Name: "dry-run", Usage: "show the new gains without applying them"},
+		&cli.BoolFlag{Name: "yes", Usage: "apply without asking"},
+	},
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		volts, amps := cmd.Float("volts"), cmd.Float("amps")
+		if volts <= 0 {
+			return fmt.Errorf("--volts is required")
+		}
+
+		if cmd.Bool("auto") {
+			if amps <= 0 {
+				return fmt.Errorf("--amps is required with --auto")
+			}
+			fmt.Printf("Starting device calibration to %.2fV %.3fA\n", volts, amps)
+			if err := k.StartCalibrationCtx(ctx, uint(volts*1000), uint(amps*1000)); err != nil {
+				return err
+			}
+			return verifyCalibration(ctx, k, volts, amps)
+		}
+
+		gain, err := k.GetEmeterGainCtx(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Sampling %d readings...\n", cmd.Int("samples"))
+		v, a, err := sampleEmeter(ctx, k, int(cmd.Int("samples")))
+		if err != nil {
+			return err
+		}
+		if amps > 0 && a == 0 {
+			return fmt.Errorf("the device reads no current, is the load switched on?")
+		}
+
+		vgain := kasa.CalibratedGain(gain.VGain, v, volts)
+		igain := gain.IGain
+		if amps > 0 {
+			igain = kasa.CalibratedGain(gain.IGain, a, amps)
+		}
+
+		fmt.Printf("Device reads:\t%.2fV %.3fA\n", v, a)
+		fmt.Printf("Reference:\t%.2fV %.3fA\n", volts, amps)
+		fmt.Printf("Voltage gain:\t%d -> %d (was off by %+.2f%%)\n", gain.VGain, vgain, percent(v, volts))
+		if amps > 0 {
+			fmt.Printf("Current gain:\t%d -> %d (was off by %+.2f%%)\n", gain.IGain, igain, percent(a, amps))
+		}
+
+		if cmd.Bool("dry-run") {
+			return nil
+		}
+		if !cmd.Bool("yes") && !confirm("Apply the new gains?") {
+			return nil
+		}
+
+		if err := k.SetEmeterGainCtx(ctx, vgain, igain); err != nil {
+			return err
+		}
+		return verifyCalibration(ctx, k, volts, amps)
+	},
+}
+
+// sampleEmeter averages several realtime readings, returned in V and A
+func sampleEmeter(ctx context.Context, k *kasa.Device, n int) (float64, float64, error) {
+	if n < 1 {
+		n = 1
+	}
+	var v, a float64
+	for i := range n {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return 0, 0, ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+		em, err := k.GetEmeterCtx(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		v += em.Volts()
+		a += em.Amps()
+	}
+	return v / float64(n), a / float64(n), nil
+}
+
+func verifyCalibration(ctx context.Context, k *kasa.Device, volts, amps float64) error {
+	// the meter needs a moment to settle on the new gains
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(2 * time.Second):
+	}
+	v, a, err := sampleEmeter(ctx, k, 1)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Device now reads:\t%.2fV (%+.2f%%)", v, percent(v, volts))
+	if amps > 0 {
+		fmt.Printf(" %.3fA (%+.2f%%)", a, percent(a, amps))
+	}
+	fmt.Println()
+	return nil
+}
+
+// percent is how far the reading is from the reference
+func percent(reading, reference float64) float64 {
+	if reference == 0 {
+		return 0
+	}
+	return (reading - reference) / reference * 100
+}
+
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	line = strings.ToLower(strings.TrimSpace(line))
+	return line == "y" || line == "yes"
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
 	"sync"
 	"text/tabwriter"
 	"time"
@@ -19,7 +21,7 @@ var brightness = &cli.Command{
 	Usage:     "set brightness",
 	UsageText: "kasa brightness host (value: 0-100)",
 	ArgsUsage: "host brightness",
-	Before:    RequireDevice,
+	Before:    RequireCapability(kasa.CapDimmer),
 	Arguments: []cli.Argument{
 		&cli.StringArg{Name: "host"},
 		&cli.IntArg{Name: "brightness"},
@@ -42,16 +44,23 @@ var dimmer = &cli.Command{
 	Name:      "dimmer",
 	Usage:     "check dimmer parameters",
 	ArgsUsage: "[host]",
-	Before:    RequireDevice,
+	Before:    RequireCapability(kasa.CapDimmer),
 	Arguments: []cli.Argument{
 		&cli.StringArg{Name: "host"},
 	},
 	Action: func(ctx context.Context, cmd *cli.Command) error {
 		k := ctx.Value("kasaDev").(*kasa.Device)
-		res, err := k.GetDimmerParametersCtx(ctx)
+		p, err := k.GetDimmerParametersCtx(ctx)
 		if err != nil {
 			return err
 		}
+		res := dimmerInfo{DimmerParameters: p}
+
+		// older firmware has no default behavior
+		res.DefaultBehavior, err = k.GetDefaultBehaviorCtx(ctx)
+		if err != nil && !errors.Is(err, kasa.ErrModuleNotSupported) && !errors.Is(err, kasa.ErrMethodNotSupported) {
+			return err
+		}
 
 		return formatOutput(cmd, res, func() {
 			tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
@@ -61,10 +70,25 @@ var dimmer = &cli.Command{
 			}
 			fmt.Fprintf(tabwrite, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n", "", k.IP, res.MinThreshold, res.FadeOnTime, res.FadeOffTime, res.GentleOnTime, res.GentleOffTime, res.RampRate)
 			_ = tabwrite.Flush()
+
+			if b := res.DefaultBehavior; b != nil {
+				tabwrite = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+				fmt.Fprintf(tabwrite, "Hard On:\t%s\n", behaviorString(b.HardOn))
+				fmt.Fprintf(tabwrite, "Soft On:\t%s\n", behaviorString(b.SoftOn))
+				fmt.Fprintf(tabwrite, "Double Click:\t%s\n", behaviorString(b.DoubleClick))
+				fmt.Fprintf(tabwrite, "Long Press:\t%s\n", behaviorString(b.LongPress))
+				_ = tabwrite.Flush()
+			}
 		})
 	},
 }
 
+// dimmerInfo is the dimmer command's result, DefaultBehavior is nil on firmware without it
+type dimmerInfo struct {
+	*kasa.DimmerParameters
+	DefaultBehavior *kasa.DefaultBehavior `json:"default_behavior,omitempty"`
+}
+
 type dimmerResult struct {
 	Alias string `json:"alias"`
 	Host  string `json:"host"`
@@ -91,7 +115,7 @@ var alldimmer = &cli.Command{
 			h, i := k, v // shadow for closure
 			g.Go(func() error {
 				if i.ErrCode == 0 {
-					kd, err := kasa.NewDevice(h)
+					kd, err := kasa.NewDevice(h, deviceOptions(cmd)...)
 					if err != nil {
 						return err
 					}
@@ -136,7 +160,7 @@ var setfadeontime = &cli.Command{
 	Name:      "setfadeontime",
 	Usage:     "set fade on time",
 	ArgsUsage: "time in ms",
-	Before:    RequireDevice,
+	Before:    RequireCapability(kasa.CapDimmer),
 	Arguments: []cli.Argument{
 		&cli.StringArg{Name: "host"},
 		&cli.IntArg{Name: "time"},
@@ -151,7 +175,7 @@ var setfadeofftime = &cli.Command{
 	Name:      "setfadeofftime",
 	Usage:     "set fade off time",
 	ArgsUsage: "time in ms",
-	Before:    RequireDevice,
+	Before:    RequireCapability(kasa.CapDimmer),
 	Arguments: []cli.Argument{
 		&cli.StringArg{Name: "host"},
 		&cli.IntArg{Name: "time"},
@@ -166,7 +190,7 @@ var setgentleontime = &cli.Command{
 	Name:      "setgentleontime",
 	Usage:     "set gentle on time",
 	ArgsUsage: "time in ms",
-	Before:    RequireDevice,
+	Before:    RequireCapability(kasa.CapDimmer),
 	Arguments: []cli.Argument{
 		&cli.StringArg{Name: "host"},
 		&cli.IntArg{Name: "time"},
@@ -181,7 +205,7 @@ var setgentleofftime = &cli.Command{
 	Name:      "setgentleofftime",
 	Usage:     "set gentle off time",
 	ArgsUsage: "time in ms",
-	Before:    RequireDevice,
+	Before:    RequireCapability(kasa.CapDimmer),
 	Arguments: []cli.Argument{
 		&cli.StringArg{Name: "host"},
 		&cli.IntArg{Name: "time"},
@@ -191,3 +215,140 @@ var setgentleofftime = &cli.Command{
 		return k.SetGentleOffTimeCtx(ctx, cmd.IntArg("time"))
 	},
 }
+
+var setdimmertransition = &cli.Command{
+	Name:      "setdimmertransition",
+	Usage:     "fade to a brightness over a time",
+	ArgsUsage: "host brightness time-in-ms",
+	Before:    RequireCapability(kasa.CapDimmer),
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+		&cli.IntArg{Name: "brightness"},
+		&cli.IntArg{Name: "time"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		return k.SetDimmerTransitionCtx(ctx, cmd.IntArg("brightness"), time.Duration(cmd.IntArg("time"))*time.Millisecond)
+	},
+}
+
+var setramprate = &cli.Command{
+	Name:      "setramprate",
+	Usage:     "set how fast holding the paddle dims (10-50)",
+	ArgsUsage: "host rate",
+	Before:    RequireCapability(kasa.CapDimmer),
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+		&cli.IntArg{Name: "rate"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		return k.SetRampRateCtx(ctx, cmd.IntArg("rate"))
+	},
+}
+
+var setminthreshold = &cli.Command{
+	Name:      "setminthreshold",
+	Usage:     "set the lowest output level (0-51)",
+	ArgsUsage: "host threshold",
+	Before:    RequireCapability(kasa.CapDimmer),
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+		&cli.IntArg{Name: "threshold"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		return k.SetMinThresholdCtx(ctx, cmd.IntArg("threshold"))
+	},
+}
+
+var setdoubleclick = &cli.Command{
+	Name:      "setdoubleclick",
+	Usage:     "set what a double click does: none, instant, gentle or preset",
+	ArgsUsage: "host action [preset]",
+	Before:    RequireCapability(kasa.CapDimmer),
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+		&cli.StringArg{Name: "action"},
+		&cli.IntArg{Name: "preset"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		a, err := kasa.ParseButtonAction(cmd.StringArg("action"))
+		if err != nil {
+			return err
+		}
+		return k.SetDoubleClickActionCtx(ctx, a, cmd.IntArg("preset"))
+	},
+}
+
+var setlongpress = &cli.Command{
+	Name:      "setlongpress",
+	Usage:     "set what holding the paddle does: none, instant, gentle or preset",
+	ArgsUsage: "host action [preset]",
+	Before:    RequireCapability(kasa.CapDimmer),
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+		&cli.StringArg{Name: "action"},
+		&cli.IntArg{Name: "preset"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		a, err := kasa.ParseButtonAction(cmd.StringArg("action"))
+		if err != nil {
+			return err
+		}
+		return k.SetLongPressActionCtx(ctx, a, cmd.IntArg("preset"))
+	},
+}
+
+var setdefaultbehavior = &cli.Command{
+	Name:      "setdefaultbehavior",
+	Usage:     "set how the dimmer comes on: last or a preset index",
+	UsageText: "kasa setdefaultbehavior [--soft] host last|preset-index\n\n   Without --soft this is power returning or the paddle, with it the app and schedules.",
+	ArgsUsage: "host last|preset",
+	Before:    RequireCapability(kasa.CapDimmer),
+	Flags: []cli.Flag{
+		&cli.BoolFlag{Name: "soft", Usage: "set the behavior for the app and schedules"},
+	},
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+		&cli.StringArg{Name: "mode"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		kind := "hard_on"
+		if cmd.Bool("soft") {
+			kind = "soft_on"
+		}
+		if m := cmd.StringArg("mode"); m == "last" || m == "" {
+			return k.SetDefaultBehaviorCtx(ctx, kind, kasa.BehaviorLastStatus, 0)
+		}
+		i, err := strconv.Atoi(cmd.StringArg("mode"))
+		if err != nil {
+			return fmt.Errorf("invalid mode %q, want last or a preset index", cmd.StringArg("mode"))
+		}
+		return k.SetDefaultBehaviorCtx(ctx, kind, kasa.BehaviorPreset, i)
+	},
+}
+
+var calibratebrightness = &cli.Command{
+	Name:      "calibratebrightness",
+	Usage:     "have the dimmer measure its load to find the usable range, the lights will flicker",
+	ArgsUsage: "host",
+	Before:    RequireCapability(kasa.CapDimmer),
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		return k.CalibrateBrightnessCtx(ctx)
+	},
+}
+
+func behaviorString(b kasa.Behavior) string {
+	if b.Index != nil {
+		return fmt.Sprintf("%s %d", b.Mode, *b.Index)
+	}
+	return b.Mode
+}
@@ -41,7 +41,7 @@ var discover = &cli.Command{
 			for _, k := range keys {
 				v := m[k]
 				if len(v.Children) == 0 {
-					fmt.Fprintf(tabwrite, "%s\t%s\t%s\t%s\t\t%3d\n", v.Alias, k, v.Model, i2o(v.RelayState), v.Brightness)
+					fmt.Fprintf(tabwrite, "%s\t%s\t%s\t%s\t\t%s\n", v.Alias, k, v.Model, b2o(v.IsOn()), level(v))
 				} else {
 					fmt.Fprintf(tabwrite, "%s\t%s\t%s\t\t\n", v.Alias, k, v.Model)
 					for _, c := range v.Children {
@@ -60,3 +60,19 @@ func i2o(o uint) string {
 	}
 	return "Off"
 }
+
+func b2o(on bool) string {
+	if on {
+		return "On"
+	}
+	return "Off"
+}
+
+// level is the brightness for dimmers and bulbs, blank for plain relays
+func level(s *kasa.Sysinfo) string {
+	c := s.Capabilities()
+	if !c.Has(kasa.CapDimmer) && !c.Has(kasa.CapDimmable) && s.Brightness == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%3d", s.Level())
+}
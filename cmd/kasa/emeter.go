@@ -49,15 +49,22 @@ var allemeter = &cli.Command{
 				dr := DimmerResult{
 					Host: kk,
 				}
-				kd, err := kasa.NewDevice(kk)
+				kd, err := kasa.NewDevice(kk, deviceOptions(cmd)...)
 				if err != nil {
 					return err
 				}
 
-				drs, err := kd.GetSettingsCtx(gctx)
+				// settings and a fresh reading in one round trip, strips still need one per outlet
+				q, err := kd.QueryCtx(gctx, kasa.NewRequest().
+					Add("system", "get_sysinfo", nil).
+					Add("emeter", "get_realtime", nil))
 				if err != nil {
 					return err
 				}
+				if err := q.Err("system", "get_sysinfo"); err != nil {
+					return err
+				}
+				drs := &q.GetSysinfo.Sysinfo
 				dr.Sysinfo = *drs
 
 				if drs.NumChildren > 0 {
@@ -68,6 +75,8 @@ var allemeter = &cli.Command{
 						}
 						dr.Realtime = append(dr.Realtime, Realtime{c, *drc})
 					}
+				} else if q.Err("emeter", "get_realtime") == nil {
+					dr.Realtime = append(dr.Realtime, Realtime{kasa.Child{}, q.Emeter.Realtime})
 				} else {
 					dr.Realtime = append(dr.Realtime, Realtime{kasa.Child{}, vv.Emeter.Realtime})
 				}
@@ -86,29 +95,25 @@ var allemeter = &cli.Command{
 				return d[i].Alias < d[j].Alias
 			})
 
-			var tma, twh, tw uint // total MA, Wh, W and
+			var ta, tkwh, tw float64 // total A, kWh, W
 			tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 			if !cmd.Bool("no-header") {
 				fmt.Fprintf(tabwrite, "Device\tCurrent\t%s\tPower\tSince Reset\n", color.GreenString("Voltage"))
 			}
 
 			for _, s := range d {
-				var ma, w, tsr uint
 				for _, cv := range s.Realtime {
 					displayName := s.Alias
 					if cv.Alias != "" {
 						displayName = fmt.Sprintf("%s/%s", s.Alias, cv.Alias)
 					}
-					fmt.Fprintf(tabwrite, "%s\t%dmA\t%s\t%2.2fW\t%2.2fkWh\n", displayName, cv.CurrentMA, colorVolts(cv.VoltageMV), float64(cv.PowerMW)/1000, float64(cv.TotalWH)/1000)
-					ma += cv.CurrentMA
-					w += cv.PowerMW
-					tsr += cv.TotalWH
+					fmt.Fprintf(tabwrite, "%s\t%s\t%s\t%2.2fW\t%2.2fkWh\n", displayName, milliAmps(cv.Amps()), colorVolts(cv.Volts()), cv.Watts(), cv.KWh())
+					ta += cv.Amps()
+					tw += cv.Watts()
+					tkwh += cv.KWh()
 				}
-				tma += ma
-				twh += tsr
-				tw += w
 			}
-			fmt.Fprintf(tabwrite, "Total House\t%dmA\t%s\t%2.2fW\t%2.2fkWh\n", tma, color.GreenString(" "), float64(tw)/1000, float64(twh)/1000)
+			fmt.Fprintf(tabwrite, "Total House\t%s\t%s\t%2.2fW\t%2.2fkWh\n", milliAmps(ta), color.GreenString(" "), tw, tkwh)
 			_ = tabwrite.Flush()
 		})
 	},
@@ -117,12 +122,20 @@ var allemeter = &cli.Command{
 var emeter = &cli.Command{
 	Name:      "emeter",
 	Usage:     "check energy usage",
-	ArgsUsage: "[host] [month] [year]",
-	Before:    RequireDevice,
+	UsageText: "kasa emeter host [month] [year]\n   kasa emeter --yearly host [year]",
+	ArgsUsage: "host [month] [year] | --yearly host [year]",
+	Before:    RequireCapability(kasa.CapEmeter),
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "yearly",
+			Usage:   "show per-month totals for the year",
+			Aliases: []string{"y"},
+		},
+	},
 	Arguments: []cli.Argument{
 		&cli.StringArg{Name: "host"},
-		&cli.IntArg{Name: "month"},
-		&cli.IntArg{Name: "year"},
+		// month and year, or only the year with --yearly
+		&cli.IntArgs{Name: "date", Max: 2},
 	},
 	Action: func(ctx context.Context, cmd *cli.Command) error {
 		k := ctx.Value("kasaDev").(*kasa.Device)
@@ -131,43 +144,51 @@ var emeter = &cli.Command{
 			return err
 		}
 
+		date := cmd.IntArgs("date")
+		year := time.Now().Year()
+
+		if cmd.Bool("yearly") {
+			if len(date) > 1 {
+				return fmt.Errorf("--yearly takes only a year")
+			}
+			if len(date) == 1 && date[0] != 0 {
+				year = date[0]
+			}
+			return emeterYear(ctx, cmd, k, s, year)
+		}
+
 		month := 0
-		mm := cmd.IntArg("month")
-		if mm != 0 {
-			if mm < 1 || mm > 12 {
+		if len(date) > 0 && date[0] != 0 {
+			if date[0] < 1 || date[0] > 12 {
 				return fmt.Errorf("invalid month")
 			}
-			month = mm
+			month = date[0]
 		}
-
-		year := time.Now().Year()
-		yy := cmd.IntArg("year")
-		if yy != 0 {
-			year = yy
+		if len(date) > 1 && date[1] != 0 {
+			year = date[1]
 		}
 
+		child := cmd.String("child")
 		tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 		if month == 0 {
 			fmt.Fprintf(tabwrite, "Device\tCurrent\t%s\tPower\tSince Reset\n", color.GreenString("Voltage"))
-			child := cmd.StringArg("child")
-			if child == "" {
-				var ma uint
-				var w, twh float64
+			if s.NumChildren > 0 && child == "" {
+				var a, w, kwh float64
 				for _, c := range s.Children {
 					cv, err := k.GetEmeterChildCtx(ctx, c.ID)
 					if err != nil {
 						continue
 					}
-					ma += cv.CurrentMA
-					w += float64(cv.PowerMW) / 1000
-					twh += float64(cv.TotalWH) / 1000
+					a += cv.Amps()
+					w += cv.Watts()
+					kwh += cv.KWh()
 					fmt.Fprintf(tabwrite, "%s\t", c.Alias)
-					fmt.Fprintf(tabwrite, "%dmA\t", cv.CurrentMA)
-					fmt.Fprintf(tabwrite, "%s\t", colorVolts(cv.VoltageMV))
-					fmt.Fprintf(tabwrite, "%2.2fW\t", float64(cv.PowerMW)/1000)
-					fmt.Fprintf(tabwrite, "%2.2fkWh\n", float64(cv.TotalWH)/1000)
+					fmt.Fprintf(tabwrite, "%s\t", milliAmps(cv.Amps()))
+					fmt.Fprintf(tabwrite, "%s\t", colorVolts(cv.Volts()))
+					fmt.Fprintf(tabwrite, "%2.2fW\t", cv.Watts())
+					fmt.Fprintf(tabwrite, "%2.2fkWh\n", cv.KWh())
 				}
-				fmt.Fprintf(tabwrite, "Total\t%dmA\t%s\t%2.2fW\t%2.2fkWh\n", ma, color.GreenString(" "), w, twh)
+				fmt.Fprintf(tabwrite, "Total\t%s\t%s\t%2.2fW\t%2.2fkWh\n", milliAmps(a), color.GreenString(" "), w, kwh)
 			} else {
 				var em *kasa.EmeterRealtime
 
@@ -179,10 +200,10 @@ var emeter = &cli.Command{
 				if err != nil {
 					return err
 				}
-				fmt.Fprintf(tabwrite, "%s\t%dmA\t", s.Alias, em.CurrentMA)
-				fmt.Fprintf(tabwrite, "%s\t", colorVolts(em.VoltageMV))
-				fmt.Fprintf(tabwrite, "%2.2fW\t", float64(em.PowerMW)/1000)
-				fmt.Fprintf(tabwrite, "%2.2fkWh\n", float64(em.TotalWH)/1000)
+				fmt.Fprintf(tabwrite, "%s\t%s\t", s.Alias, milliAmps(em.Amps()))
+				fmt.Fprintf(tabwrite, "%s\t", colorVolts(em.Volts()))
+				fmt.Fprintf(tabwrite, "%2.2fW\t", em.Watts())
+				fmt.Fprintf(tabwrite, "%2.2fkWh\n", em.KWh())
 			}
 			_ = tabwrite.Flush()
 			return nil
@@ -220,8 +241,118 @@ var emeter = &cli.Command{
 	},
 }
 
-func colorVolts(mv uint) string {
-	vVolts := float64(mv) / 1000.0
+type yearResult struct {
+	Alias  string             `json:"alias"`
+	ID     string             `json:"id,omitempty"`
+	Months []kasa.EmeterMonth `json:"months"`
+	Total  uint               `json:"total_wh"`
+}
+
+// emeterYear prints the per-month totals, one column per outlet on strips
+func emeterYear(ctx context.Context, cmd *cli.Command, k *kasa.Device, s *kasa.Sysinfo, year int) error {
+	var res []yearResult
+	if s.NumChildren > 0 {
+		for _, c := range s.Children {
+			if child := cmd.String("child"); child != "" && child != c.ID {
+				continue
+			}
+			ms, err := k.GetEmeterChildYearCtx(ctx, year, c.ID)
+			if err != nil {
+				return err
+			}
+			res = append(res, yearResult{Alias: c.Alias, ID: c.ID, Months: ms.List, Total: ms.Total()})
+		}
+	} else {
+		ms, err := k.GetEmeterYearCtx(ctx, year)
+		if err != nil {
+			return err
+		}
+		res = append(res, yearResult{Alias: s.Alias, Months: ms.List, Total: ms.Total()})
+	}
+
+	return formatOutput(cmd, res, func() {
+		tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		if !cmd.Bool("no-header") {
+			fmt.Fprintf(tabwrite, "Month\t")
+			for _, r := range res {
+				fmt.Fprintf(tabwrite, "%s\t", r.Alias)
+			}
+			if len(res) > 1 {
+				fmt.Fprintf(tabwrite, "Strip Total\t")
+			}
+			fmt.Fprintln(tabwrite)
+		}
+
+		var total uint
+		for m := 1; m <= 12; m++ {
+			var row uint
+			line := fmt.Sprintf("%d-%02d\t", year, m)
+			found := false
+			for _, r := range res {
+				wh, ok := monthWH(r.Months, m)
+				found = found || ok
+				row += wh
+				line += fmt.Sprintf("%2.2fkWh\t", float64(wh)/1000)
+			}
+			if !found {
+				continue
+			}
+			if len(res) > 1 {
+				line += fmt.Sprintf("%2.2fkWh\t", float64(row)/1000)
+			}
+			total += row
+			fmt.Fprintln(tabwrite, line)
+		}
+
+		fmt.Fprintf(tabwrite, "Total\t")
+		for _, r := range res {
+			fmt.Fprintf(tabwrite, "%2.2fkWh\t", float64(r.Total)/1000)
+		}
+		if len(res) > 1 {
+			fmt.Fprintf(tabwrite, "%2.2fkWh\t", float64(total)/1000)
+		}
+		fmt.Fprintln(tabwrite)
+		_ = tabwrite.Flush()
+	})
+}
+
+func monthWH(months []kasa.EmeterMonth, m int) (uint, bool) {
+	for _, v := range months {
+		if int(v.Month) == m {
+			return v.WH, true
+		}
+	}
+	return 0, false
+}
+
+var eraseemeter = &cli.Command{
+	Name:      "eraseemeter",
+	Usage:     "erase the energy history and since-reset total",
+	ArgsUsage: "host --yes",
+	Before:    RequireCapability(kasa.CapEmeter),
+	Flags: []cli.Flag{
+		&cli.BoolFlag{Name: "yes", Usage: "really erase, this cannot be undone"},
+	},
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		if !cmd.Bool("yes") {
+			return fmt.Errorf("this erases all energy history, add --yes to confirm")
+		}
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		if child := cmd.String("child"); child != "" {
+			return k.EraseEmeterStatsChildCtx(ctx, child)
+		}
+		return k.EraseEmeterStatsCtx(ctx)
+	},
+}
+
+func milliAmps(a float64) string {
+	return fmt.Sprintf("%.0fmA", a*1000)
+}
+
+func colorVolts(vVolts float64) string {
 	vStr := fmt.Sprintf("%2.2fV", vVolts)
 	coloredVolt := ""
 	switch {
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudkucooland/go-kasa"
+	"github.com/urfave/cli/v3"
+)
+
+type firmwareResult struct {
+	Host      string `json:"host"`
+	Alias     string `json:"alias"`
+	Model     string `json:"model"`
+	HWVersion string `json:"hw_ver"`
+	SWVersion string `json:"sw_ver"`
+	Available string `json:"available,omitempty"`
+}
+
+var firmware = &cli.Command{
+	Name:  "firmware",
+	Usage: "firmware inventory and updates",
+	Commands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "list the hardware and firmware versions of every discovered device",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "check", Usage: "ask each device's cloud for available updates"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				bctx, cancel := context.WithTimeout(ctx, time.Duration(cmd.Int("timeout"))*time.Second)
+				defer cancel()
+
+				m, err := kasa.BroadcastDiscovery(bctx, int(cmd.Int("repeats")))
+				if err != nil {
+					return err
+				}
+
+				res := make([]firmwareResult, 0, len(m))
+				for host, s := range m {
+					res = append(res, firmwareResult{
+						Host:      host,
+						Alias:     s.Alias,
+						Model:     s.Model,
+						HWVersion: s.HWVersion,
+						SWVersion: s.SWVersion,
+					})
+				}
+				sort.Slice(res, func(i, j int) bool {
+					if res[i].Model != res[j].Model {
+						return res[i].Model < res[j].Model
+					}
+					return res[i].Host < res[j].Host
+				})
+
+				if cmd.Bool("check") {
+					var wg sync.WaitGroup
+					for i := range res {
+						wg.Go(func() {
+							res[i].Available = availableFirmware(ctx, cmd, res[i].Host)
+						})
+					}
+					wg.Wait()
+				}
+
+				return formatOutput(cmd, res, func() {
+					tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					if !cmd.Bool("no-header") {
+						fmt.Fprintf(tabwrite, "Host\tAlias\tModel\tHardware\tFirmware\tAvailable\n")
+					}
+					for _, r := range res {
+						fmt.Fprintf(tabwrite, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Host, r.Alias, r.Model, r.HWVersion, r.SWVersion, r.Available)
+					}
+					_ = tabwrite.Flush()
+				})
+			},
+		},
+		{
+			Name:      "check",
+			Usage:     "show the updates the cloud offers for a device",
+			ArgsUsage: "host",
+			Before:    RequireDevice,
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				l, err := k.GetFirmwareListCtx(ctx)
+				if err != nil {
+					return err
+				}
+
+				return formatOutput(cmd, l.List, func() {
+					if len(l.List) == 0 {
+						fmt.Println("No updates available")
+						return
+					}
+					tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					if !cmd.Bool("no-header") {
+						fmt.Fprintf(tabwrite, "Version\tReleased\tURL\n")
+					}
+					for _, f := range l.List {
+						fmt.Fprintf(tabwrite, "%s\t%s\t%s\n", f.Version, f.ReleaseDate, f.URL)
+					}
+					_ = tabwrite.Flush()
+				})
+			},
+		},
+		{
+			Name:  "update",
+			Usage: "download a firmware image from a local http server and flash it",
+			UsageText: `kasa firmware update --url http://192.168.1.10:8000/HS103v2_1.0.6.bin host
+
+   Serve the image from a machine on the same network, e.g. "python3 -m http.server" in its directory.
+   The device only fetches over plain http. It reboots when flashing completes.`,
+			ArgsUsage: "host",
+			Before:    RequireDevice,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "url", Usage: "http URL of the firmware image", Required: true},
+				&cli.BoolFlag{Name: "yes", Usage: "flash without asking"},
+				&cli.DurationFlag{Name: "download-timeout", Usage: "give up if the download has not finished", Value: 5 * time.Minute},
+			},
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				s, err := k.GetSettingsCtx(ctx)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s: %s (%s) running %s\n", s.Alias, s.Model, s.HWVersion, s.SWVersion)
+
+				if err := k.DownloadFirmwareCtx(ctx, cmd.String("url")); err != nil {
+					return err
+				}
+				dctx, cancel := context.WithTimeout(ctx, cmd.Duration("download-timeout"))
+				state, err := k.WaitForDownloadCtx(dctx, time.Second, func(d *kasa.DownloadState) {
+					fmt.Printf("\rDownloading: %3d%%", d.Ratio)
+				})
+				cancel()
+				fmt.Println()
+				if err != nil {
+					return err
+				}
+
+				if !cmd.Bool("yes") && !confirm("Download complete, flash it? The device must not lose power while flashing.") {
+					return nil
+				}
+				if err := k.FlashFirmwareCtx(ctx); err != nil {
+					return err
+				}
+				fmt.Printf("Flashing, the device will reboot in about %ds\n", state.FlashTime+state.RebootTime)
+				return nil
+			},
+		},
+	},
+}
+
+// availableFirmware is the newest version the cloud offers, or a short reason there is none
+func availableFirmware(ctx context.Context, cmd *cli.Command, host string) string {
+	k, err := newDevice(ctx, cmd, host)
+	if err != nil {
+		return "error"
+	}
+	l, err := k.GetFirmwareListCtx(ctx)
+	if err != nil {
+		return "unknown"
+	}
+	if len(l.List) == 0 {
+		return "current"
+	}
+	return l.List[0].Version
+}
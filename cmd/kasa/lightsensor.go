@@ -3,6 +3,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
 
 	"github.com/cloudkucooland/go-kasa"
 	"github.com/urfave/cli/v3"
@@ -12,7 +15,7 @@ var lightsensorbrightness = &cli.Command{
 	Name:      "ambient",
 	Usage:     "get ambient brightness",
 	UsageText: "kasa ambient host",
-	Before:    RequireDevice,
+	Before:    RequireCapability(kasa.CapLightSensor),
 	ArgsUsage: "host",
 	Arguments: []cli.Argument{
 		&cli.StringArg{Name: "host"},
@@ -30,21 +33,98 @@ var lightsensorbrightness = &cli.Command{
 
 var lightsensorconfig = &cli.Command{
 	Name:      "lightsensor",
-	Usage:     "get light sensor config",
-	UsageText: "kasa lightsensor host",
-	Before:    RequireDevice,
+	Usage:     "get or change the ambient light sensor config",
+	UsageText: "kasa lightsensor host\n   kasa lightsensor enable|darklevel|level host ...",
 	ArgsUsage: "host",
 	Arguments: []cli.Argument{
 		&cli.StringArg{Name: "host"},
 	},
 	Action: func(ctx context.Context, cmd *cli.Command) error {
+		// not a Before hook, it would also run ahead of the subcommands
+		ctx, err := RequireCapability(kasa.CapLightSensor)(ctx, cmd)
+		if err != nil {
+			return err
+		}
 		k := ctx.Value("kasaDev").(*kasa.Device)
 
 		c, err := k.GetLightSensorConfigCtx(ctx)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("%+v\n", c)
-		return nil
+
+		return formatOutput(cmd, c, func() {
+			tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, dev := range c.Devs {
+				fmt.Fprintf(tabwrite, "Sensor %d\tenabled: %d\tADC range: %d-%d\n", dev.ID, dev.Enable, dev.MinADC, dev.MaxADC)
+				if !cmd.Bool("no-header") {
+					fmt.Fprintf(tabwrite, "Index\tLevel\tADC\tBrightness\tDark\n")
+				}
+				for i, l := range dev.Levels {
+					dark := ""
+					if uint(i) == dev.DarkIndex {
+						dark = "*"
+					}
+					fmt.Fprintf(tabwrite, "%d\t%s\t%d\t%d\t%s\n", i, l.Name, l.ADC, l.Value, dark)
+				}
+			}
+			_ = tabwrite.Flush()
+		})
+	},
+	Commands: []*cli.Command{
+		{
+			Name:      "enable",
+			Usage:     "turn the light sensor on or off",
+			ArgsUsage: "host true|false",
+			Before:    RequireCapability(kasa.CapLightSensor),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "state"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				b, err := strconv.ParseBool(cmd.StringArg("state"))
+				if err != nil {
+					return err
+				}
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				return k.SetLightSensorEnableCtx(ctx, b)
+			},
+		},
+		{
+			Name:      "darklevel",
+			Usage:     "choose the level at which it is considered dark, by name or index",
+			ArgsUsage: "host level",
+			Before:    RequireCapability(kasa.CapLightSensor),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "level"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				level := cmd.StringArg("level")
+				if i, err := strconv.ParseUint(level, 10, 32); err == nil {
+					return k.SetLightSensorDarkIndexCtx(ctx, uint(i))
+				}
+				return k.SetLightSensorDarkLevelCtx(ctx, level)
+			},
+		},
+		{
+			Name:      "level",
+			Usage:     "change the brightness value of a named level",
+			ArgsUsage: "host name value",
+			Before:    RequireCapability(kasa.CapLightSensor),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "name"},
+				&cli.IntArg{Name: "value"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				v := cmd.IntArg("value")
+				if v < 0 || v > 100 {
+					return fmt.Errorf("invalid value (0-100)")
+				}
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				return k.SetLightSensorLevelCtx(ctx, cmd.StringArg("name"), uint(v))
+			},
+		},
 	},
 }
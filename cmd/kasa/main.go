@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/cloudkucooland/go-kasa"
 
@@ -49,6 +52,11 @@ func main() {
 				Aliases: []string{"t"},
 				Value:   2,
 			},
+			&cli.BoolFlag{
+				Name:    "ack",
+				Usage:   "wait for the device to acknowledge changes",
+				Aliases: []string{"a"},
+			},
 			&cli.IntFlag{
 				Name:    "port",
 				Value:   9999,
@@ -62,7 +70,7 @@ func main() {
 			{
 				Name:      "info",
 				Usage:     "show basic info",
-				UsageText: "kasa info host",
+				UsageText: "kasa info host|id:deviceID|mac:address",
 				Before:    RequireDevice,
 				ArgsUsage: "host",
 				Arguments: []cli.Argument{
@@ -71,10 +79,18 @@ func main() {
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					k := ctx.Value("kasaDev").(*kasa.Device)
 
-					s, err := k.GetSettingsCtx(ctx)
+					// one round trip, sections the device doesn't support are skipped
+					q, err := k.QueryCtx(ctx, kasa.NewRequest().
+						Add("system", "get_sysinfo", nil).
+						Add("netif", "get_stainfo", nil).
+						Add("emeter", "get_realtime", nil))
 					if err != nil {
 						return err
 					}
+					if err := q.Err("system", "get_sysinfo"); err != nil {
+						return err
+					}
+					s := &q.GetSysinfo.Sysinfo
 
 					tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 
@@ -86,17 +102,28 @@ func main() {
 					fmt.Fprintf(tabwrite, "Hardware ID:\t%s\n", s.HWID)
 					fmt.Fprintf(tabwrite, "Software:\t%s\n", s.SWVersion)
 					fmt.Fprintf(tabwrite, "MIC:\t%s\n", s.MIC)
-					fmt.Fprintf(tabwrite, "MAC:\t%s\n", s.MAC)
+					fmt.Fprintf(tabwrite, "MAC:\t%s\n", s.MACAddress())
 					fmt.Fprintf(tabwrite, "LED Off:\t%d\n", s.LEDOff)
 					fmt.Fprintf(tabwrite, "Active Mode:\t%s\n", s.ActiveMode)
+					fmt.Fprintf(tabwrite, "Capabilities:\t%s\n", s.Capabilities())
+					if q.Err("netif", "get_stainfo") == nil {
+						fmt.Fprintf(tabwrite, "WiFi:\t%s (%ddB)\n", q.NetIf.StaInfo.SSID, q.NetIf.StaInfo.RSSI)
+					}
+					if q.Err("emeter", "get_realtime") == nil && s.NumChildren == 0 {
+						em := q.Emeter.Realtime
+						fmt.Fprintf(tabwrite, "Power:\t%2.2fW %s %s\n", em.Watts(), milliAmps(em.Amps()), colorVolts(em.Volts()))
+					}
 
 					fmt.Fprintf(tabwrite, "Outlet\tRelay State\tBrightness\n")
 					if s.NumChildren > 0 {
 						for _, v := range s.Children {
-							fmt.Fprintf(tabwrite, "%s\t%d\t\n", v.Alias, v.RelayState)
+							fmt.Fprintf(tabwrite, "%s\t%s\t\n", v.Alias, i2o(v.RelayState))
 						}
 					} else {
-						fmt.Fprintf(tabwrite, "\t%d\t%d\n", s.RelayState, s.Brightness)
+						fmt.Fprintf(tabwrite, "\t%s\t%s\n", b2o(s.IsOn()), level(s))
+					}
+					if l := s.LightState; l != nil && l.IsOn() {
+						fmt.Fprintf(tabwrite, "Light:\t%s\n", lightString(l))
 					}
 					_ = tabwrite.Flush()
 					return nil
@@ -121,10 +148,10 @@ func main() {
 					fmt.Fprintf(tabwrite, "Device\tOutlet\tRelay State\tBrightness\n")
 					if s.NumChildren > 0 {
 						for _, v := range s.Children {
-							fmt.Fprintf(tabwrite, "%s\t%s\t%d\t\n", s.Alias, v.Alias, v.RelayState)
+							fmt.Fprintf(tabwrite, "%s\t%s\t%s\t\n", s.Alias, v.Alias, i2o(v.RelayState))
 						}
 					} else {
-						fmt.Fprintf(tabwrite, "%s\t\t%d\t%d\n", s.Alias, s.RelayState, s.Brightness)
+						fmt.Fprintf(tabwrite, "%s\t\t%s\t%s\n", s.Alias, b2o(s.IsOn()), level(s))
 					}
 					_ = tabwrite.Flush()
 					return nil
@@ -154,6 +181,12 @@ func main() {
 			},
 			alias,
 			emeter,
+			eraseemeter,
+			calibrate,
+			timeCmd,
+			firmware,
+			bulb,
+			strip,
 			allemeter,
 			dimmer,
 			alldimmer,
@@ -165,16 +198,28 @@ func main() {
 			setfadeofftime,
 			setgentleontime,
 			setgentleofftime,
+			setdimmertransition,
+			setramprate,
+			setminthreshold,
+			setdoubleclick,
+			setlongpress,
+			setdefaultbehavior,
+			calibratebrightness,
 			reboot,
 			nocloud,
 			cloud,
 			ledoff,
 			addcountdown,
 			cleancountdown,
+			deletecountdown,
+			editcountdown,
 			countdown,
+			schedule,
+			away,
 			setmode,
 			lightsensorbrightness,
 			lightsensorconfig,
+			pir,
 			raw,
 		},
 	}
@@ -182,6 +227,12 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 	if err := cmd.Run(ctx, os.Args); err != nil {
+		switch {
+		case errors.Is(err, kasa.ErrModuleNotSupported), errors.Is(err, kasa.ErrMethodNotSupported):
+			err = fmt.Errorf("device does not support this command: %w", err)
+		case errors.Is(err, kasa.ErrTimeout):
+			err = fmt.Errorf("device did not respond in time: %w", err)
+		}
 		if cmd.Bool("json") {
 			status := map[string]any{"success": false, "error": err.Error()}
 			_ = json.NewEncoder(os.Stdout).Encode(status)
@@ -196,20 +247,74 @@ func main() {
 }
 
 func RequireDevice(ctx context.Context, cmd *cli.Command) (context.Context, error) {
-	host := cmd.Args().Get(0)
+	host := hostArg(cmd)
 	if host == "" {
 		return ctx, fmt.Errorf("host argument is required for this command")
 	}
 
-	k, err := kasa.NewDevice(host)
+	k, err := newDevice(ctx, cmd, host)
 	if err != nil {
 		return ctx, fmt.Errorf("failed to initialize device: %w", err)
 	}
-	k.Port = int(cmd.Int("port"))
 
 	return context.WithValue(ctx, "kasaDev", k), nil
 }
 
+// hostArg is the first argument, or the parsed host argument when called from an Action
+func hostArg(cmd *cli.Command) string {
+	if host := cmd.Args().Get(0); host != "" {
+		return host
+	}
+	return cmd.StringArg("host")
+}
+
+// RequireCapability is RequireDevice for commands that only make sense on some models
+func RequireCapability(c kasa.Capabilities) cli.BeforeFunc {
+	return func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+		ctx, err := RequireDevice(ctx, cmd)
+		if err != nil {
+			return ctx, err
+		}
+
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		caps, err := k.CapabilitiesCtx(ctx)
+		if err != nil {
+			return ctx, err
+		}
+		if !caps.Has(c) {
+			return ctx, fmt.Errorf("%s does not support %s (has: %s)", hostArg(cmd), c, caps)
+		}
+		return ctx, nil
+	}
+}
+
+// newDevice accepts a hostname, an IP address, "id:<device ID>" or "mac:<MAC address>"
+func newDevice(ctx context.Context, cmd *cli.Command, host string) (*kasa.Device, error) {
+	opts := deviceOptions(cmd)
+
+	if id, ok := strings.CutPrefix(host, "id:"); ok {
+		return kasa.NewDeviceByID(ctx, id, opts...)
+	}
+	if mac, ok := strings.CutPrefix(host, "mac:"); ok {
+		return kasa.NewDeviceByMAC(ctx, mac, opts...)
+	}
+	return kasa.NewDevice(host, opts...)
+}
+
+// deviceOptions applies the global flags to every device the command talks to
+func deviceOptions(cmd *cli.Command) []kasa.Option {
+	timeout := time.Duration(cmd.Int("timeout")) * time.Second
+	opts := []kasa.Option{
+		kasa.WithPort(int(cmd.Int("port"))),
+		kasa.WithDialTimeout(timeout),
+		kasa.WithReadTimeout(timeout),
+	}
+	if cmd.Bool("ack") {
+		opts = append(opts, kasa.WithAcknowledgedUDP(2))
+	}
+	return opts
+}
+
 func formatOutput(cmd *cli.Command, data any, pretty func()) error {
 	if cmd.Bool("json") {
 		enc := json.NewEncoder(os.Stdout)
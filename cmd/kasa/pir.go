@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudkucooland/go-kasa"
+	"github.com/urfave/cli/v3"
+)
+
+type pirResult struct {
+	Enable     bool   `json:"enable"`
+	Range      string `json:"range"`
+	RangeValue uint   `json:"range_value"`
+	ColdTime   uint   `json:"cold_time_seconds"`
+	Presets    []uint `json:"presets"`
+}
+
+var pir = &cli.Command{
+	Name:  "pir",
+	Usage: "motion sensor settings (KS200M, ES20M)",
+	Commands: []*cli.Command{
+		{
+			Name:      "show",
+			Usage:     "show the motion sensor settings",
+			ArgsUsage: "host",
+			Before:    RequireCapability(kasa.CapMotion),
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				c, err := k.GetPIRConfigCtx(ctx)
+				if err != nil {
+					return err
+				}
+
+				res := pirResult{
+					Enable:     c.Enable == 1,
+					Range:      c.Range().String(),
+					RangeValue: c.RangeValue(),
+					ColdTime:   uint(c.Cold() / time.Second),
+					Presets:    c.Data,
+				}
+				return formatOutput(cmd, res, func() {
+					tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+					fmt.Fprintf(tabwrite, "Enabled:\t%t\n", res.Enable)
+					fmt.Fprintf(tabwrite, "Range:\t%s (%d)\n", res.Range, res.RangeValue)
+					fmt.Fprintf(tabwrite, "Cold Time:\t%ds\n", res.ColdTime)
+					fmt.Fprintf(tabwrite, "Presets:\t%s\n", presetValues(c.Data))
+					_ = tabwrite.Flush()
+				})
+			},
+		},
+		{
+			Name:      "enable",
+			Usage:     "turn the motion sensor on or off",
+			ArgsUsage: "host true|false",
+			Before:    RequireCapability(kasa.CapMotion),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "state"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				b, err := strconv.ParseBool(cmd.StringArg("state"))
+				if err != nil {
+					return err
+				}
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				return k.SetPIREnableCtx(ctx, b)
+			},
+		},
+		{
+			Name:      "coldtime",
+			Usage:     "how long the light stays on after the last motion",
+			ArgsUsage: "host seconds",
+			Before:    RequireCapability(kasa.CapMotion),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.IntArg{Name: "seconds"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				s := cmd.IntArg("seconds")
+				if s < 1 {
+					return fmt.Errorf("invalid cold time")
+				}
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				return k.SetPIRColdTimeCtx(ctx, time.Duration(s)*time.Second)
+			},
+		},
+		{
+			Name:      "range",
+			Usage:     "set the trigger range: near, mid, far or a custom value",
+			ArgsUsage: "host near|mid|far|value",
+			Before:    RequireCapability(kasa.CapMotion),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "range"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				arg := cmd.StringArg("range")
+				if v, err := strconv.ParseUint(arg, 10, 32); err == nil {
+					return k.SetPIRCustomRangeCtx(ctx, uint(v))
+				}
+				r, err := kasa.ParsePIRRange(arg)
+				if err != nil {
+					return err
+				}
+				return k.SetPIRRangeCtx(ctx, r)
+			},
+		},
+	},
+}
+
+// presetValues labels the far, mid, near and custom values
+func presetValues(data []uint) string {
+	var p []string
+	for i, v := range data {
+		p = append(p, fmt.Sprintf("%s %d", kasa.PIRRange(i), v))
+	}
+	return strings.Join(p, ", ")
+}
@@ -23,7 +23,7 @@ var addcountdown = &cli.Command{
 	},
 	Action: func(ctx context.Context, cmd *cli.Command) error {
 		k := ctx.Value("kasaDev").(*kasa.Device)
-		dur := cmd.Int("duration")
+		dur := cmd.IntArg("duration")
 		if dur < 1 || dur > 3600 {
 			return fmt.Errorf("invalid duration (1-3600)")
 		}
@@ -31,6 +31,9 @@ var addcountdown = &cli.Command{
 		if err != nil {
 			return err
 		}
+		if child := cmd.String("child"); child != "" {
+			return k.AddCountdownRuleChildCtx(ctx, child, dur, b, "auto")
+		}
 		return k.AddCountdownRuleCtx(ctx, dur, b, "auto")
 	},
 }
@@ -45,10 +48,87 @@ var cleancountdown = &cli.Command{
 	},
 	Action: func(ctx context.Context, cmd *cli.Command) error {
 		k := ctx.Value("kasaDev").(*kasa.Device)
+		if child := cmd.String("child"); child != "" {
+			return k.ClearCountdownRulesChildCtx(ctx, child)
+		}
 		return k.ClearCountdownRulesCtx(ctx)
 	},
 }
 
+var deletecountdown = &cli.Command{
+	Name:      "deletecountdown",
+	Usage:     "remove one countdown rule",
+	Before:    RequireDevice,
+	ArgsUsage: "host id",
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+		&cli.StringArg{Name: "id"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		if child := cmd.String("child"); child != "" {
+			return k.DeleteCountdownRuleChildCtx(ctx, child, cmd.StringArg("id"))
+		}
+		return k.DeleteCountdownRuleCtx(ctx, cmd.StringArg("id"))
+	},
+}
+
+var editcountdown = &cli.Command{
+	Name:      "editcountdown",
+	Usage:     "change a countdown rule, a duration of 0 pauses it",
+	Before:    RequireDevice,
+	ArgsUsage: "host id duration True|False",
+	Arguments: []cli.Argument{
+		&cli.StringArg{Name: "host"},
+		&cli.StringArg{Name: "id"},
+		&cli.IntArg{Name: "duration"},
+		&cli.StringArg{Name: "target"},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		k := ctx.Value("kasaDev").(*kasa.Device)
+		dur := cmd.IntArg("duration")
+		if dur < 0 || dur > 3600 {
+			return fmt.Errorf("invalid duration (0-3600)")
+		}
+		b, err := strconv.ParseBool(cmd.StringArg("target"))
+		if err != nil {
+			return err
+		}
+
+		child := cmd.String("child")
+		var rules []kasa.Rule
+		if child != "" {
+			rules, err = k.GetCountdownRulesChildCtx(ctx, child)
+		} else {
+			rules, err = k.GetCountdownRulesCtx(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		var r *kasa.Rule
+		for i := range rules {
+			if rules[i].ID == cmd.StringArg("id") {
+				r = &rules[i]
+			}
+		}
+		if r == nil {
+			return fmt.Errorf("no countdown rule with id %s", cmd.StringArg("id"))
+		}
+
+		r.Delay, r.Enable, r.Active = uint(dur), 1, 0
+		if dur == 0 {
+			r.Enable = 0
+		}
+		if b {
+			r.Active = 1
+		}
+		if child != "" {
+			return k.EditCountdownRuleChildCtx(ctx, child, *r)
+		}
+		return k.EditCountdownRuleCtx(ctx, *r)
+	},
+}
+
 var countdown = &cli.Command{
 	Name:      "getcountdown",
 	Usage:     "view countdown rules",
@@ -57,7 +137,13 @@ var countdown = &cli.Command{
 	Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
 	Action: func(ctx context.Context, cmd *cli.Command) error {
 		k := ctx.Value("kasaDev").(*kasa.Device)
-		res, err := k.GetCountdownRulesCtx(ctx)
+		var res []kasa.Rule
+		var err error
+		if child := cmd.String("child"); child != "" {
+			res, err = k.GetCountdownRulesChildCtx(ctx, child)
+		} else {
+			res, err = k.GetCountdownRulesCtx(ctx)
+		}
 		if err != nil {
 			return err
 		}
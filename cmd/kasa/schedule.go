@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/cloudkucooland/go-kasa"
+	"github.com/urfave/cli/v3"
+)
+
+// ruleFlags are shared by schedule add and schedule edit
+func ruleFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "days", Usage: "mon,tue,... weekend, workdays or everyday", Value: "everyday"},
+		&cli.StringFlag{Name: "at", Usage: "start time: HH:MM, sunrise[+-min] or sunset[+-min]"},
+		&cli.StringFlag{Name: "action", Usage: "on or off", Value: "on"},
+		&cli.StringFlag{Name: "until", Usage: "end time, the opposite action is run then"},
+		&cli.IntFlag{Name: "brightness", Usage: "brightness to set on dimmers (1-100)"},
+	}
+}
+
+var schedule = &cli.Command{
+	Name:  "schedule",
+	Usage: "manage device schedules",
+	Commands: []*cli.Command{
+		{
+			Name:      "list",
+			Usage:     "list the schedule rules",
+			ArgsUsage: "host",
+			Before:    RequireDevice,
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				res, err := k.GetScheduleRulesCtx(ctx)
+				if err != nil {
+					return err
+				}
+
+				return formatOutput(cmd, res, func() {
+					tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					if !cmd.Bool("no-header") {
+						fmt.Fprintf(tabwrite, "Schedule enabled: %d\n", res.Enable)
+						fmt.Fprintf(tabwrite, "ID\tName\tEnable\tDays\tStart\tAction\tEnd\tAction\tBrightness\n")
+					}
+					for _, r := range res.Rules {
+						end, eact := r.End(), ""
+						if end != "" {
+							eact = actionName(r.EndAct)
+						}
+						bright := ""
+						if r.Light != nil {
+							bright = strconv.Itoa(r.Light.Brightness)
+						}
+						fmt.Fprintf(tabwrite, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Name, r.Enable, r.Weekdays, r.Start(), actionName(r.StartAct), end, eact, bright)
+					}
+					_ = tabwrite.Flush()
+				})
+			},
+		},
+		{
+			Name:      "add",
+			Usage:     "add a schedule rule",
+			UsageText: "kasa schedule add --days workdays --at sunset-15 --until 23:00 host name",
+			ArgsUsage: "host name",
+			Before:    RequireDevice,
+			Flags:     ruleFlags(),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "name"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				if !cmd.IsSet("at") {
+					return fmt.Errorf("--at is required")
+				}
+
+				r := kasa.ScheduleRule{
+					Name:   cmd.StringArg("name"),
+					Enable: 1,
+					Repeat: 1,
+					EndOpt: kasa.TimeNone,
+					EndAct: -1,
+				}
+				if err := applyRuleFlags(cmd, &r); err != nil {
+					return err
+				}
+
+				id, err := k.AddScheduleRuleCtx(ctx, r)
+				if err != nil {
+					return err
+				}
+				return formatOutput(cmd, map[string]string{"id": id}, func() {
+					fmt.Println(id)
+				})
+			},
+		},
+		{
+			Name:      "edit",
+			Usage:     "change an existing schedule rule, only the flags given are changed",
+			ArgsUsage: "host id",
+			Before:    RequireDevice,
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "rename the rule"},
+			}, ruleFlags()...),
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "id"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				r, err := findScheduleRule(ctx, k, cmd.StringArg("id"))
+				if err != nil {
+					return err
+				}
+				if cmd.IsSet("name") {
+					r.Name = cmd.String("name")
+				}
+				if err := applyRuleFlags(cmd, r); err != nil {
+					return err
+				}
+				return k.EditScheduleRuleCtx(ctx, *r)
+			},
+		},
+		{
+			Name:      "delete",
+			Usage:     "delete a schedule rule",
+			ArgsUsage: "host id",
+			Before:    RequireDevice,
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "id"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				return k.DeleteScheduleRuleCtx(ctx, cmd.StringArg("id"))
+			},
+		},
+		{
+			Name:      "clear",
+			Usage:     "delete every schedule rule",
+			ArgsUsage: "host",
+			Before:    RequireDevice,
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				return k.DeleteAllScheduleRulesCtx(ctx)
+			},
+		},
+		{
+			Name:      "enable",
+			Usage:     "enable the schedule, or a single rule",
+			ArgsUsage: "host [id]",
+			Before:    RequireDevice,
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "id"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				return enableSchedule(ctx, cmd, true)
+			},
+		},
+		{
+			Name:      "disable",
+			Usage:     "disable the schedule, or a single rule",
+			ArgsUsage: "host [id]",
+			Before:    RequireDevice,
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "id"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				return enableSchedule(ctx, cmd, false)
+			},
+		},
+	},
+}
+
+func enableSchedule(ctx context.Context, cmd *cli.Command, enable bool) error {
+	k := ctx.Value("kasaDev").(*kasa.Device)
+	id := cmd.StringArg("id")
+	if id == "" {
+		return k.EnableScheduleRulesCtx(ctx, enable)
+	}
+
+	r, err := findScheduleRule(ctx, k, id)
+	if err != nil {
+		return err
+	}
+	r.Enable = 0
+	if enable {
+		r.Enable = 1
+	}
+	return k.EditScheduleRuleCtx(ctx, *r)
+}
+
+func findScheduleRule(ctx context.Context, k *kasa.Device, id string) (*kasa.ScheduleRule, error) {
+	res, err := k.GetScheduleRulesCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range res.Rules {
+		if res.Rules[i].ID == id {
+			return &res.Rules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no schedule rule with id %s", id)
+}
+
+// applyRuleFlags copies the flags which were given (or all of them for a new rule) into r
+func applyRuleFlags(cmd *cli.Command, r *kasa.ScheduleRule) error {
+	isNew := r.ID == ""
+
+	if isNew || cmd.IsSet("days") {
+		w, err := kasa.ParseWeekdays(cmd.String("days"))
+		if err != nil {
+			return err
+		}
+		r.Weekdays = w
+	}
+
+	if cmd.IsSet("at") {
+		opt, min, err := kasa.ParseRuleTime(cmd.String("at"))
+		if err != nil {
+			return err
+		}
+		r.StartOpt, r.StartMin = opt, min
+	}
+
+	if isNew || cmd.IsSet("action") {
+		act, err := parseAction(cmd.String("action"))
+		if err != nil {
+			return err
+		}
+		r.StartAct = act
+		if r.EndAct >= 0 {
+			r.EndAct = 1 - act
+		}
+	}
+
+	if cmd.IsSet("until") {
+		opt, min, err := kasa.ParseRuleTime(cmd.String("until"))
+		if err != nil {
+			return err
+		}
+		r.EndOpt, r.EndMin, r.EndAct = opt, min, 1-r.StartAct
+	}
+
+	if cmd.IsSet("brightness") {
+		b := int(cmd.Int("brightness"))
+		if b < 1 || b > 100 {
+			return fmt.Errorf("invalid brightness (1-100)")
+		}
+		if r.Light == nil {
+			r.Light = &kasa.ScheduleLight{}
+		}
+		r.Light.Brightness = b
+	}
+	return nil
+}
+
+func parseAction(s string) (int, error) {
+	switch s {
+	case "on", "1", "true":
+		return 1, nil
+	case "off", "0", "false":
+		return 0, nil
+	}
+	return 0, fmt.Errorf("invalid action %q (on|off)", s)
+}
+
+func actionName(act int) string {
+	switch act {
+	case 0:
+		return "off"
+	case 1:
+		return "on"
+	case 2:
+		return "toggle"
+	}
+	return ""
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloudkucooland/go-kasa"
+	"github.com/urfave/cli/v3"
+)
+
+var strip = &cli.Command{
+	Name:  "strip",
+	Usage: "light strip zones and effects (KL400, KL420, KL430)",
+	Commands: []*cli.Command{
+		{
+			Name:      "show",
+			Usage:     "show the running effect and zone colors",
+			ArgsUsage: "host",
+			Before:    RequireCapability(kasa.CapLightStrip),
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				s, err := k.GetSettingsCtx(ctx)
+				if err != nil {
+					return err
+				}
+				st, err := k.GetStripStateCtx(ctx)
+				if err != nil {
+					return err
+				}
+
+				return formatOutput(cmd, st, func() {
+					tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+					fmt.Fprintf(tabwrite, "State:\t%s\n", i2o(uint(st.OnOff)))
+					fmt.Fprintf(tabwrite, "Zones:\t%d\n", s.Length)
+					if e := s.EffectState; e != nil && e.Enable == 1 {
+						fmt.Fprintf(tabwrite, "Effect:\t%s (%d%%)\n", e.Name, e.Brightness)
+					} else {
+						fmt.Fprintf(tabwrite, "Effect:\tnone\n")
+					}
+					for _, z := range st.Groups {
+						fmt.Fprintf(tabwrite, "%d-%d:\t%s\n", z.First, z.Last, presetString(kasa.Preset{Hue: z.Hue, Saturation: z.Saturation, ColorTemp: z.ColorTemp, Brightness: uint(z.Brightness)}))
+					}
+					_ = tabwrite.Flush()
+				})
+			},
+		},
+		{
+			Name:  "effects",
+			Usage: "list the built-in effects",
+			UsageText: `kasa strip effects
+
+   Only some of the app's effects are built in. Christmas, Hanukkah, Haunted Mansion, Icicle, Valentines
+   and the other holiday effects are missing, start them with kasa strip effect --file definition.json.`,
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				names := kasa.EffectNames()
+				return formatOutput(cmd, names, func() {
+					for _, n := range names {
+						fmt.Println(n)
+					}
+				})
+			},
+		},
+		{
+			Name:      "effect",
+			Usage:     "start a built-in effect by name, or a custom one from a JSON file",
+			UsageText: "kasa strip effect host Aurora\n   kasa strip effect --file custom.json host",
+			ArgsUsage: "host [name]",
+			Before:    RequireCapability(kasa.CapLightStrip),
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "file", Usage: "JSON effect definition, see kasa.LightingEffect"},
+				&cli.IntFlag{Name: "brightness", Usage: "override the effect brightness (1-100)"},
+			},
+			Arguments: []cli.Argument{
+				&cli.StringArg{Name: "host"},
+				&cli.StringArg{Name: "name"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+
+				var e kasa.LightingEffect
+				if f := cmd.String("file"); f != "" {
+					b, err := os.ReadFile(f)
+					if err != nil {
+						return err
+					}
+					if err := json.Unmarshal(b, &e); err != nil {
+						return fmt.Errorf("%s: %w", f, err)
+					}
+					e.Custom, e.Enable = 1, 1
+				} else {
+					var ok bool
+					if e, ok = kasa.BuiltinEffect(cmd.StringArg("name")); !ok {
+						return fmt.Errorf("unknown effect %q, see kasa strip effects", cmd.StringArg("name"))
+					}
+				}
+				if b := cmd.Int("brightness"); b > 0 {
+					e.Brightness = int(b)
+				}
+				return k.SetLightingEffectCtx(ctx, e)
+			},
+		},
+		{
+			Name:      "stop",
+			Usage:     "stop the running effect",
+			ArgsUsage: "host",
+			Before:    RequireCapability(kasa.CapLightStrip),
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				return k.StopLightingEffectCtx(ctx)
+			},
+		},
+		{
+			Name:  "zones",
+			Usage: "color runs of zones",
+			UsageText: `kasa strip zones host 0-7:0,100,100 8-15:120,100,100
+
+   Each run is first[-last]:hue,saturation,brightness or first[-last]:kelvinK,brightness`,
+			ArgsUsage: "host run [run...]",
+			Before:    RequireCapability(kasa.CapLightStrip),
+			Flags:     transitionFlag(),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				args := cmd.Args().Tail()
+				if len(args) == 0 {
+					return fmt.Errorf("at least one run of zones is required")
+				}
+
+				zones := make([]kasa.StripZone, 0, len(args))
+				for _, a := range args {
+					z, err := parseZone(a)
+					if err != nil {
+						return err
+					}
+					zones = append(zones, z)
+				}
+				return k.SetStripZonesCtx(ctx, zones, transition(cmd))
+			},
+		},
+	},
+}
+
+// parseZone reads first[-last]:hue,saturation,brightness or first[-last]:kelvinK,brightness
+func parseZone(s string) (kasa.StripZone, error) {
+	var z kasa.StripZone
+	rng, color, ok := strings.Cut(s, ":")
+	if !ok {
+		return z, fmt.Errorf("invalid run %q, want first-last:color", s)
+	}
+
+	first, last, isRange := strings.Cut(rng, "-")
+	var err error
+	if z.First, err = strconv.Atoi(first); err != nil {
+		return z, fmt.Errorf("invalid zone in %q", s)
+	}
+	z.Last = z.First
+	if isRange {
+		if z.Last, err = strconv.Atoi(last); err != nil {
+			return z, fmt.Errorf("invalid zone in %q", s)
+		}
+	}
+
+	parts := strings.Split(color, ",")
+	if kelvin, ok := strings.CutSuffix(strings.ToUpper(parts[0]), "K"); ok {
+		if len(parts) != 2 {
+			return z, fmt.Errorf("invalid color in %q, want kelvinK,brightness", s)
+		}
+		if z.ColorTemp, err = strconv.Atoi(kelvin); err != nil {
+			return z, fmt.Errorf("invalid color temperature in %q", s)
+		}
+		if z.Brightness, err = strconv.Atoi(parts[1]); err != nil {
+			return z, fmt.Errorf("invalid brightness in %q", s)
+		}
+		return z, nil
+	}
+
+	if len(parts) != 3 {
+		return z, fmt.Errorf("invalid color in %q, want hue,saturation,brightness", s)
+	}
+	v := make([]int, 3)
+	for i, p := range parts {
+		if v[i], err = strconv.Atoi(p); err != nil {
+			return z, fmt.Errorf("invalid color in %q", s)
+		}
+	}
+	z.Hue, z.Saturation, z.Brightness = v[0], v[1], v[2]
+	return z, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	_ "time/tzdata" // the kasa zone table needs zoneinfo on every platform
+
+	"github.com/cloudkucooland/go-kasa"
+	"github.com/urfave/cli/v3"
+)
+
+type timeResult struct {
+	Time     time.Time `json:"time"`
+	Zone     string    `json:"zone"`
+	Index    int       `json:"index"`
+	DriftSec int       `json:"drift_seconds"`
+}
+
+var timeCmd = &cli.Command{
+	Name:  "time",
+	Usage: "device clock and timezone",
+	Commands: []*cli.Command{
+		{
+			Name:      "show",
+			Usage:     "show the device's clock, zone and drift from this host",
+			ArgsUsage: "host",
+			Before:    RequireDevice,
+			Arguments: []cli.Argument{&cli.StringArg{Name: "host"}},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				k := ctx.Value("kasaDev").(*kasa.Device)
+				z, err := k.GetTimezoneCtx(ctx)
+				if err != nil {
+					return err
+				}
+				t, err := k.GetTimeCtx(ctx)
+				if err != nil {
+					return err
+				}
+				now := time.Now()
+
+				loc, err := z.Location()
+				if err != nil {
+					loc = time.UTC
+				}
+				dt := t.Time(loc)
+				res := timeResult{
+					Time:     dt,
+					Zone:     z.Name(),
+					Index:    z.Index,
+					DriftSec: int(dt.Sub(now).Round(time.Second) / time.Second),
+				}
+				return formatOutput(cmd, res, func() {
+					tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+					fmt.Fprintf(tabwrite, "Time:\t%s\n", dt.Format("2006-01-02 15:04:05 MST"))
+					fmt.Fprintf(tabwrite, "Zone:\t%s (%d)\n", res.Zone, res.Index)
+					fmt.Fprintf(tabwrite, "Drift:\t%+ds\n", res.DriftSec)
+					_ = tabwrite.Flush()
+				})
+			},
+		},
+		{
+			Name:      "sync",
+			Usage:     "set the clock and zone from this host",
+			UsageText: "kasa time sync host [host...]\n   kasa time sync --all",
+			ArgsUsage: "host [host...]",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "all", Usage: "sync every device that answers discovery"},
+				&cli.StringFlag{Name: "zone", Usage: "IANA zone to set instead of this host's"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				loc, err := hostLocation(cmd.String("zone"))
+				if err != nil {
+					return err
+				}
+				index, err := kasa.TimezoneIndex(loc)
+				if err != nil {
+					return err
+				}
+				name, _ := kasa.TimezoneName(index)
+				fmt.Printf("Setting zone %s (%d)\n", name, index)
+
+				sync := func(ctx context.Context, k *kasa.Device) error {
+					return k.SetTimezoneCtx(ctx, time.Now().In(loc))
+				}
+				if !cmd.Bool("all") {
+					return eachHost(ctx, cmd, sync)
+				}
+
+				bctx, cancel := context.WithTimeout(ctx, time.Duration(cmd.Int("timeout"))*time.Second)
+				defer cancel()
+				m, err := kasa.BroadcastDiscovery(bctx, int(cmd.Int("repeats")))
+				if err != nil {
+					return err
+				}
+				hosts := make([]string, 0, len(m))
+				for h := range m {
+					hosts = append(hosts, h)
+				}
+				sort.Strings(hosts)
+				fmt.Printf("Found %d devices\n", len(hosts))
+				return eachOf(ctx, cmd, hosts, sync)
+			},
+		},
+	},
+}
+
+// hostLocation is the named zone, or this host's zone by its IANA name so the table lookup can match it
+func hostLocation(zone string) (*time.Location, error) {
+	if zone == "" {
+		zone = strings.TrimPrefix(os.Getenv("TZ"), ":")
+	}
+	if zone == "" {
+		if l, err := os.Readlink("/etc/localtime"); err == nil {
+			if _, name, ok := strings.Cut(l, "zoneinfo/"); ok {
+				zone = name
+			}
+		}
+	}
+	if zone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(zone)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"text/tabwriter"
 	"time"
@@ -16,10 +18,20 @@ import (
 )
 
 var setwifi = &cli.Command{
-	Name:      "setwifi",
-	Usage:     "configure wifi",
+	Name:  "setwifi",
+	Usage: "configure wifi",
+	UsageText: `kasa setwifi host ssid key
+   kasa setwifi --scan host
+
+   The device scans first: the key type is taken from the scan, and you are warned
+   if the device can't see the network, since joining it would take the device offline.`,
 	ArgsUsage: "host ssid key",
 	Before:    RequireDevice,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{Name: "scan", Usage: "only list the networks the device can see"},
+		&cli.StringFlag{Name: "keytype", Usage: "open, wep, wpa or wpa2, instead of taking it from the scan"},
+		&cli.BoolFlag{Name: "yes", Usage: "join without asking, even if the device can't see the network"},
+	},
 	Arguments: []cli.Argument{
 		&cli.StringArg{Name: "host"},
 		&cli.StringArg{Name: "ssid"},
@@ -27,11 +39,76 @@ var setwifi = &cli.Command{
 	},
 	Action: func(ctx context.Context, cmd *cli.Command) error {
 		k := ctx.Value("kasaDev").(*kasa.Device)
-		_, err := k.SetWIFICtx(ctx, cmd.StringArg("ssid"), cmd.StringArg("key"))
+		ssid := cmd.StringArg("ssid")
+
+		scan, scanErr := k.ScanWIFICtx(ctx)
+		if cmd.Bool("scan") || ssid == "" {
+			if scanErr != nil {
+				return scanErr
+			}
+			return formatOutput(cmd, scan.List, func() {
+				tabwrite := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				if !cmd.Bool("no-header") {
+					fmt.Fprintf(tabwrite, "SSID\tKey Type\tRSSI\n")
+				}
+				for _, ap := range scan.List {
+					fmt.Fprintf(tabwrite, "%s\t%s\t%s\n", ap.SSID, keyType(ap.KeyType), colorRSSI(ap.RSSI))
+				}
+				_ = tabwrite.Flush()
+			})
+		}
+
+		kt := kasa.KeyDefault
+		ap, seen := kasa.AccessPoint{}, false
+		if scanErr != nil {
+			fmt.Fprintf(os.Stderr, "scan failed: %s\n", scanErr.Error())
+		} else {
+			ap, seen = scan.Find(ssid)
+		}
+
+		switch {
+		case cmd.String("keytype") != "":
+			var err error
+			if kt, err = parseKeyType(cmd.String("keytype")); err != nil {
+				return err
+			}
+		case seen:
+			kt = ap.KeyType
+			fmt.Printf("%s: %s %s\n", ssid, keyType(kt), colorRSSI(ap.RSSI))
+		}
+
+		if !seen {
+			// after a failed scan there is nothing to say about visibility
+			if scanErr == nil {
+				fmt.Fprintf(os.Stderr, "%s\n", color.YellowString("the device can't see %q, if it can't join it will drop off the network", ssid))
+			}
+			if !cmd.Bool("yes") && !confirm("Join anyway?") {
+				return nil
+			}
+		}
+
+		_, err := k.SetWIFIKeyTypeCtx(ctx, ssid, cmd.StringArg("key"), kt)
 		return err
 	},
 }
 
+func parseKeyType(s string) (kasa.KeyType, error) {
+	switch strings.ToLower(s) {
+	case "open", "none":
+		return kasa.KeyOpen, nil
+	case "wep":
+		return kasa.KeyWEP, nil
+	case "wpa":
+		return kasa.KeyWPA, nil
+	case "wpa2":
+		return kasa.KeyWPA2, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return kasa.KeyType(n), nil
+	}
+	return 0, fmt.Errorf("unknown key type %q, want open, wep, wpa or wpa2", s)
+}
+
 var wifi = &cli.Command{
 	Name:      "wifi",
 	Usage:     "check device wifi status",
@@ -83,7 +160,7 @@ var allwifi = &cli.Command{
 		for host, info := range m {
 			h, i := host, info // shadow for closure
 			g.Go(func() error {
-				kd, _ := kasa.NewDevice(h)
+				kd, _ := kasa.NewDevice(h, deviceOptions(cmd)...)
 				s, err := kd.GetSettingsCtx(gctx)
 				if err != nil {
 					return nil // skip if offline
@@ -127,13 +204,13 @@ func colorRSSI(rssi int) string {
 	}
 }
 
-func keyType(t int) string {
+func keyType(t kasa.KeyType) string {
 	switch t {
-	case 3:
-		return color.GreenString("WPA3")
-	case 2:
-		return color.YellowString("WPA2")
+	case kasa.KeyWPA2:
+		return color.GreenString(t.String())
+	case kasa.KeyWPA:
+		return color.YellowString(t.String())
 	default:
-		return color.RedString("Unknown")
+		return color.RedString(t.String())
 	}
 }
@@ -3,9 +3,7 @@ package kasa
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"strings"
 )
 
 func boolToInt(b bool) int {
@@ -21,8 +19,8 @@ func (d *Device) SetRelayState(newstate bool) error {
 }
 
 func (d *Device) SetRelayStateCtx(ctx context.Context, newstate bool) error {
-	cmd := fmt.Sprintf(CmdSetRelayState, boolToInt(newstate))
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("system", "set_relay_state", Params{"state": boolToInt(newstate)})
+	return d.send(ctx, r)
 }
 
 // SetRelayStateChild adjusts a single relay on a multi-relay device
@@ -31,8 +29,8 @@ func (d *Device) SetRelayStateChild(childID string, newstate bool) error {
 }
 
 func (d *Device) SetRelayStateChildCtx(ctx context.Context, childID string, newstate bool) error {
-	cmd := fmt.Sprintf(CmdSetRelayStateChild, childID, boolToInt(newstate))
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("system", "set_relay_state", Params{"state": boolToInt(newstate)}).Children(childID)
+	return d.send(ctx, r)
 }
 
 // SetRelayStateChildMulti adjusts multiple relays on a multi-relay device
@@ -41,13 +39,8 @@ func (d *Device) SetRelayStateChildMulti(newstate bool, children ...string) erro
 }
 
 func (d *Device) SetRelayStateChildMultiCtx(ctx context.Context, newstate bool, children ...string) error {
-	quoted := make([]string, len(children))
-	for i, c := range children {
-		quoted[i] = `"` + c + `"`
-	}
-
-	cmd := fmt.Sprintf(CmdSetRelayStateChildMulti, strings.Join(quoted, ","), boolToInt(newstate))
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("system", "set_relay_state", Params{"state": boolToInt(newstate)}).Children(children...)
+	return d.send(ctx, r)
 }
 
 func (d *Device) SendRawCommand(cmd string) ([]byte, error) {
@@ -68,8 +61,8 @@ func (d *Device) SetBrightness(newval int) error {
 }
 
 func (d *Device) SetBrightnessCtx(ctx context.Context, newval int) error {
-	cmd := fmt.Sprintf(CmdSetBrightness, newval)
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("smartlife.iot.dimmer", "set_brightness", Params{"brightness": newval})
+	return d.send(ctx, r)
 }
 
 func (d *Device) SetFadeOffTime(newval int) error {
@@ -77,8 +70,8 @@ func (d *Device) SetFadeOffTime(newval int) error {
 }
 
 func (d *Device) SetFadeOffTimeCtx(ctx context.Context, newval int) error {
-	cmd := fmt.Sprintf(CmdSetFadeOffTime, newval)
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("smartlife.iot.dimmer", "set_fade_off_time", Params{"fadeTime": newval})
+	return d.send(ctx, r)
 }
 
 func (d *Device) SetFadeOnTime(newval int) error {
@@ -86,8 +79,8 @@ func (d *Device) SetFadeOnTime(newval int) error {
 }
 
 func (d *Device) SetFadeOnTimeCtx(ctx context.Context, newval int) error {
-	cmd := fmt.Sprintf(CmdSetFadeOnTime, newval)
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("smartlife.iot.dimmer", "set_fade_on_time", Params{"fadeTime": newval})
+	return d.send(ctx, r)
 }
 
 func (d *Device) SetGentleOffTime(newval int) error {
@@ -95,8 +88,8 @@ func (d *Device) SetGentleOffTime(newval int) error {
 }
 
 func (d *Device) SetGentleOffTimeCtx(ctx context.Context, newval int) error {
-	cmd := fmt.Sprintf(CmdSetGentleOffTime, newval)
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("smartlife.iot.dimmer", "set_gentle_off_time", Params{"fadeTime": newval})
+	return d.send(ctx, r)
 }
 
 func (d *Device) SetGentleOnTime(newval int) error {
@@ -104,8 +97,8 @@ func (d *Device) SetGentleOnTime(newval int) error {
 }
 
 func (d *Device) SetGentleOnTimeCtx(ctx context.Context, newval int) error {
-	cmd := fmt.Sprintf(CmdSetGentleOnTime, newval)
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("smartlife.iot.dimmer", "set_gentle_on_time", Params{"fadeTime": newval})
+	return d.send(ctx, r)
 }
 
 // GetSettings gets the device sys info
@@ -114,21 +107,11 @@ func (d *Device) GetSettings() (*Sysinfo, error) {
 }
 
 func (d *Device) GetSettingsCtx(ctx context.Context) (*Sysinfo, error) {
-	res, err := d.sendTCP(ctx, CmdGetSysinfo)
-	if err != nil {
-		return nil, err
-	}
-
-	var kd KasaDevice
-	if err = json.Unmarshal(res, &kd); err != nil {
+	var s Sysinfo
+	if err := d.call(ctx, NewRequest().Add("system", "get_sysinfo", nil), &s); err != nil {
 		return nil, err
 	}
-
-	if err := kd.GetSysinfo.Sysinfo.KasaErr.OK(); err != nil {
-		return nil, err
-	}
-
-	return &kd.GetSysinfo.Sysinfo, nil
+	return &s, nil
 }
 
 // GetEmeter returns emeter data from the device
@@ -137,21 +120,11 @@ func (d *Device) GetEmeter() (*EmeterRealtime, error) {
 }
 
 func (d *Device) GetEmeterCtx(ctx context.Context) (*EmeterRealtime, error) {
-	res, err := d.sendTCP(ctx, CmdGetEmeter)
-	if err != nil {
+	var em EmeterRealtime
+	if err := d.call(ctx, NewRequest().Add("emeter", "get_realtime", nil), &em); err != nil {
 		return nil, err
 	}
-
-	var kd KasaDevice
-	if err = json.Unmarshal(res, &kd); err != nil {
-		return nil, err
-	}
-
-	if err := kd.Emeter.Realtime.KasaErr.OK(); err != nil {
-		return nil, err
-	}
-
-	return &kd.Emeter.Realtime, nil
+	return &em, nil
 }
 
 // GetEmeterMonth returns a single month's emeter data from the device
@@ -160,23 +133,13 @@ func (d *Device) GetEmeterMonth(month, year int) (*EmeterDaystat, error) {
 }
 
 func (d *Device) GetEmeterMonthCtx(ctx context.Context, month, year int) (*EmeterDaystat, error) {
-	q := fmt.Sprintf(CmdEmeterGetMonth, month, year)
-
-	res, err := d.sendTCP(ctx, q)
-	if err != nil {
-		return nil, err
-	}
+	r := NewRequest().Add("emeter", "get_daystat", Params{"month": month, "year": year})
 
-	var kd KasaDevice
-	if err = json.Unmarshal(res, &kd); err != nil {
+	var ds EmeterDaystat
+	if err := d.call(ctx, r, &ds); err != nil {
 		return nil, err
 	}
-
-	if err := kd.Emeter.DayStat.KasaErr.OK(); err != nil {
-		return nil, err
-	}
-
-	return &kd.Emeter.DayStat, nil
+	return &ds, nil
 }
 
 // GetEmeter returns emeter data from the device
@@ -185,23 +148,13 @@ func (d *Device) GetEmeterChild(child string) (*EmeterRealtime, error) {
 }
 
 func (d *Device) GetEmeterChildCtx(ctx context.Context, child string) (*EmeterRealtime, error) {
-	q := fmt.Sprintf(CmdGetEmeterChild, child)
-
-	res, err := d.sendTCP(ctx, q)
-	if err != nil {
-		return nil, err
-	}
-
-	var kd KasaDevice
-	if err = json.Unmarshal(res, &kd); err != nil {
-		return nil, err
-	}
+	r := NewRequest().Add("emeter", "get_realtime", nil).Children(child)
 
-	if err := kd.Emeter.Realtime.KasaErr.OK(); err != nil {
+	var em EmeterRealtime
+	if err := d.call(ctx, r, &em); err != nil {
 		return nil, err
 	}
-
-	return &kd.Emeter.Realtime, nil
+	return &em, nil
 }
 
 func (d *Device) GetEmeterChildMonth(month int, year int, child string) (*EmeterDaystat, error) {
@@ -209,23 +162,59 @@ func (d *Device) GetEmeterChildMonth(month int, year int, child string) (*Emeter
 }
 
 func (d *Device) GetEmeterChildMonthCtx(ctx context.Context, month int, year int, child string) (*EmeterDaystat, error) {
-	q := fmt.Sprintf(CmdGetEmeterMonthChild, child, month, year)
+	r := NewRequest().Add("emeter", "get_daystat", Params{"month": month, "year": year}).Children(child)
 
-	res, err := d.sendTCP(ctx, q)
-	if err != nil {
+	var ds EmeterDaystat
+	if err := d.call(ctx, r, &ds); err != nil {
 		return nil, err
 	}
+	return &ds, nil
+}
 
-	var kd KasaDevice
-	if err = json.Unmarshal(res, &kd); err != nil {
+// GetEmeterYear returns the per-month totals for a year from the device
+func (d *Device) GetEmeterYear(year int) (*EmeterMonthstat, error) {
+	return d.GetEmeterYearCtx(context.Background(), year)
+}
+
+func (d *Device) GetEmeterYearCtx(ctx context.Context, year int) (*EmeterMonthstat, error) {
+	var ms EmeterMonthstat
+	if err := d.call(ctx, NewRequest().Add("emeter", "get_monthstat", Params{"year": year}), &ms); err != nil {
 		return nil, err
 	}
+	return &ms, nil
+}
 
-	if err := kd.Emeter.Realtime.KasaErr.OK(); err != nil {
+// GetEmeterChildYear returns the per-month totals for a year from one outlet of a strip
+func (d *Device) GetEmeterChildYear(year int, child string) (*EmeterMonthstat, error) {
+	return d.GetEmeterChildYearCtx(context.Background(), year, child)
+}
+
+func (d *Device) GetEmeterChildYearCtx(ctx context.Context, year int, child string) (*EmeterMonthstat, error) {
+	r := NewRequest().Add("emeter", "get_monthstat", Params{"year": year}).Children(child)
+
+	var ms EmeterMonthstat
+	if err := d.call(ctx, r, &ms); err != nil {
 		return nil, err
 	}
+	return &ms, nil
+}
+
+// EraseEmeterStats clears the device's energy history and its since-reset total
+func (d *Device) EraseEmeterStats() error {
+	return d.EraseEmeterStatsCtx(context.Background())
+}
+
+func (d *Device) EraseEmeterStatsCtx(ctx context.Context) error {
+	return d.call(ctx, NewRequest().Add("emeter", "erase_emeter_stat", json.RawMessage("null")), nil)
+}
 
-	return &kd.Emeter.DayStat, nil
+// EraseEmeterStatsChild clears the energy history of one outlet of a strip
+func (d *Device) EraseEmeterStatsChild(child string) error {
+	return d.EraseEmeterStatsChildCtx(context.Background(), child)
+}
+
+func (d *Device) EraseEmeterStatsChildCtx(ctx context.Context, child string) error {
+	return d.call(ctx, NewRequest().Add("emeter", "erase_emeter_stat", json.RawMessage("null")).Children(child), nil)
 }
 
 // DisableCloud sets the device to "local only" mode.
@@ -235,7 +224,7 @@ func (d *Device) DisableCloud() error {
 }
 
 func (d *Device) DisableCloudCtx(ctx context.Context) error {
-	return d.sendUDP(ctx, CmdCloudUnbind)
+	return d.send(ctx, NewRequest().Add("cnCloud", "unbind", json.RawMessage("null")))
 }
 
 // Enable/Configure Cloud
@@ -244,8 +233,8 @@ func (d *Device) EnableCloud(username, password string) error {
 }
 
 func (d *Device) EnableCloudCtx(ctx context.Context, username, password string) error {
-	cmd := fmt.Sprintf(CmdSetServerCreds, username, password)
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("cnCloud", "bind", Params{"username": username, "password": password})
+	return d.send(ctx, r)
 }
 
 // Reboot instructs the device to reboot
@@ -254,7 +243,7 @@ func (d *Device) Reboot() error {
 }
 
 func (d *Device) RebootCtx(ctx context.Context) error {
-	return d.sendUDP(ctx, CmdReboot)
+	return d.send(ctx, NewRequest().Add("system", "reboot", Params{"delay": 2}))
 }
 
 // SetLEDOff is insanely named... it should be SetLED, but I'm just going with what TP-Link called these things internally...
@@ -263,8 +252,8 @@ func (d *Device) SetLEDOff(t bool) error {
 }
 
 func (d *Device) SetLEDOffCtx(ctx context.Context, t bool) error {
-	cmd := fmt.Sprintf(CmdLEDOff, boolToInt(t))
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("system", "set_led_off", Params{"off": boolToInt(t)})
+	return d.send(ctx, r)
 }
 
 // SetAlias sets a device name
@@ -273,8 +262,8 @@ func (d *Device) SetAlias(s string) error {
 }
 
 func (d *Device) SetAliasCtx(ctx context.Context, s string) error {
-	cmd := fmt.Sprintf(CmdDeviceAlias, s)
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("system", "set_dev_alias", Params{"alias": s})
+	return d.send(ctx, r)
 }
 
 // SetChildAlias sets the name of an individual relay on a multi-relay device, I don't think this works
@@ -283,8 +272,8 @@ func (d *Device) SetChildAlias(childID, s string) error {
 }
 
 func (d *Device) SetChildAliasCtx(ctx context.Context, childID, s string) error {
-	cmd := fmt.Sprintf(CmdChildAlias, childID, s)
-	return d.sendUDP(ctx, cmd)
+	r := NewRequest().Add("system", "set_dev_alias", Params{"alias": s}).Children(childID)
+	return d.send(ctx, r)
 }
 
 // SetMode sets the target mode of the system
@@ -293,22 +282,8 @@ func (d *Device) SetMode(m string) error {
 }
 
 func (d *Device) SetModeCtx(ctx context.Context, m string) error {
-	cmd := fmt.Sprintf(CmdSetMode, m)
-	res, err := d.sendTCP(ctx, cmd)
-	if err != nil {
-		return err
-	}
-
-	var kd KasaDevice
-	if err = json.Unmarshal(res, &kd); err != nil {
-		return err
-	}
-
-	if err := kd.GetSysinfo.Sysinfo.KasaErr.OK(); err != nil {
-		return err
-	}
-
-	return nil
+	r := NewRequest().Add("system", "set_mode", Params{"mode": m})
+	return d.call(ctx, r, nil)
 }
 
 // GetWIFIStatus returns the WiFi station info
@@ -317,24 +292,15 @@ func (d *Device) GetWIFIStatus() (*StaInfo, error) {
 }
 
 func (d *Device) GetWIFIStatusCtx(ctx context.Context) (*StaInfo, error) {
-	res, err := d.sendTCP(ctx, CmdWifiStainfo)
-	if err != nil {
-		return nil, err
-	}
-
-	var ksta KasaDevice
-	if err := json.Unmarshal(res, &ksta); err != nil {
-		return nil, err
-	}
-
-	if err := ksta.NetIf.StaInfo.KasaErr.OK(); err != nil {
+	var sta StaInfo
+	if err := d.call(ctx, NewRequest().Add("netif", "get_stainfo", nil), &sta); err != nil {
 		return nil, err
 	}
-
-	return &ksta.NetIf.StaInfo, nil
+	return &sta, nil
 }
 
-// SetWIFI configures the WiFi station info
+// SetWIFI configures the WiFi station info, the key type is taken from a scan.
+// The scan takes a few seconds, use SetWIFIKeyType if the key type is already known.
 func (d *Device) SetWIFI(ssid string, key string) (*SetStaInfo, error) {
 	return d.SetWIFICtx(context.Background(), ssid, key)
 }
@@ -343,29 +309,19 @@ func (d *Device) SetWIFICtx(ctx context.Context, ssid string, key string) (*SetS
 	if ssid == "" {
 		return nil, fmt.Errorf("no ssid specified")
 	}
-	if key == "" {
-		return nil, fmt.Errorf("no key specified")
-	}
 
-	cmd := fmt.Sprintf(CmdWifiSetStainfo, ssid, key, 4)
-	res, err := d.sendTCP(ctx, cmd)
+	scan, err := d.ScanWIFICtx(ctx)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("cannot scan for the key type of %q, use SetWIFIKeyType: %w", ssid, err)
 	}
 
-	var ksta KasaDevice
-	if err := json.Unmarshal(res, &ksta); err != nil {
-		return nil, err
+	kt := KeyDefault
+	if ap, ok := scan.Find(ssid); ok {
+		kt = ap.KeyType
+	} else {
+		d.log().Printf("%q not found in the scan, sending key type %d", ssid, kt)
 	}
-
-	if err := ksta.NetIf.SetStaInfo.KasaErr.OK(); err != nil {
-		return nil, err
-	}
-	if err := ksta.NetIf.KasaErr.OK(); err != nil {
-		return nil, err
-	}
-
-	return &ksta.NetIf.SetStaInfo, nil
+	return d.SetWIFIKeyTypeCtx(ctx, ssid, key, kt)
 }
 
 // GetDimmerParameters returns the dimmer parameters from dimmer-capable devices
@@ -374,84 +330,122 @@ func (d *Device) GetDimmerParameters() (*DimmerParameters, error) {
 }
 
 func (d *Device) GetDimmerParametersCtx(ctx context.Context) (*DimmerParameters, error) {
-	res, err := d.sendTCP(ctx, CmdGetDimmer)
-	if err != nil {
+	var dp DimmerParameters
+	if err := d.call(ctx, NewRequest().Add("smartlife.iot.dimmer", "get_dimmer_parameters", nil), &dp); err != nil {
 		return nil, err
 	}
-	var kd KasaDevice
-	if err := json.Unmarshal(res, &kd); err != nil {
-		return nil, err
-	}
-
-	if err := kd.Dimmer.KasaErr.OK(); err != nil {
-		return nil, err
-	}
-
-	return &kd.Dimmer.Parameters, nil
+	return &dp, nil
 }
 
-// GetRules returns the rule information from a device
-/*
-func (d *Device) GetRules() (string, error) {
-	return d.GetRulesCtx(context.Background())
-}
-
-func (d *Device) GetRulesCtx(ctx context.Context) (string, error) {
-	res, err := d.sendTCP(ctx, CmdGetRules)
-	if err != nil {
-		return nil, err
-	}
-	var kd KasaDevice
-	if err := json.Unmarshal(res, &kd); err != nil {
-		return nil, err
-	}
-
-	if err := kd.Rules.KasaErr.OK(); err != nil {
-		return nil, err
-	}
-	return kd.Rules, err
-} */
-
 // GetCountdownRules returns a list of the countdown timers on a device
 func (d *Device) GetCountdownRules() ([]Rule, error) {
 	return d.GetCountdownRulesCtx(context.Background())
 }
 
 func (d *Device) GetCountdownRulesCtx(ctx context.Context) ([]Rule, error) {
-	res, err := d.sendTCP(ctx, CmdGetCountdownRules)
-	if err != nil {
+	var rules GetRules
+	if err := d.getRules(ctx, "count_down", &rules); err != nil {
 		return nil, err
 	}
+	return rules.RuleList, nil
+}
 
-	var kd KasaDevice
-	if err = json.Unmarshal(res, &kd); err != nil {
-		return nil, err
-	}
+// GetCountdownRulesChild returns the countdown timers for one outlet of a multi-relay device
+func (d *Device) GetCountdownRulesChild(childID string) ([]Rule, error) {
+	return d.GetCountdownRulesChildCtx(context.Background(), childID)
+}
 
-	if err := kd.Countdown.GetRules.OK(); err != nil {
+func (d *Device) GetCountdownRulesChildCtx(ctx context.Context, childID string) ([]Rule, error) {
+	var rules GetRules
+	if err := d.getRules(ctx, "count_down", &rules, childID); err != nil {
 		return nil, err
 	}
-
-	return kd.Countdown.GetRules.RuleList, nil
+	return rules.RuleList, nil
 }
 
-// ClearCountdownRules resets all countdown rules on the device
+// ClearCountdownRules resets all countdown rules on the device.
+// It waits for the device's reply over TCP and picks the dimmer namespace from the cached capabilities.
 func (d *Device) ClearCountdownRules() error {
 	return d.ClearCountdownRulesCtx(context.Background())
 }
 
 func (d *Device) ClearCountdownRulesCtx(ctx context.Context) error {
-	return d.sendUDP(ctx, CmdDeleteAllRules)
+	return d.deleteAllRules(ctx, "count_down")
+}
+
+// ClearCountdownRulesChild resets all countdown rules on one outlet of a multi-relay device
+func (d *Device) ClearCountdownRulesChild(childID string) error {
+	return d.ClearCountdownRulesChildCtx(context.Background(), childID)
 }
 
-// AddCountdownRule adds a new countdown
+func (d *Device) ClearCountdownRulesChildCtx(ctx context.Context, childID string) error {
+	return d.deleteAllRules(ctx, "count_down", childID)
+}
+
+// AddCountdownRule adds a new countdown.
+// It waits for the device's reply over TCP and picks the dimmer namespace from the cached capabilities.
 func (d *Device) AddCountdownRule(dur int, target bool, name string) error {
 	return d.AddCountdownRuleCtx(context.Background(), dur, target, name)
 }
 
 func (d *Device) AddCountdownRuleCtx(ctx context.Context, dur int, target bool, name string) error {
-	cmd := fmt.Sprintf(CmdAddCountdownRule, dur, boolToInt(target), name)
-	return d.sendUDP(ctx, cmd)
+	_, err := d.addRule(ctx, "count_down", countdownParams(Rule{Enable: 1, Delay: uint(dur), Active: uint(boolToInt(target)), Name: name}))
+	return err
+}
+
+// AddCountdownRuleChild adds a new countdown to one outlet of a multi-relay device
+func (d *Device) AddCountdownRuleChild(childID string, dur int, target bool, name string) error {
+	return d.AddCountdownRuleChildCtx(context.Background(), childID, dur, target, name)
+}
+
+func (d *Device) AddCountdownRuleChildCtx(ctx context.Context, childID string, dur int, target bool, name string) error {
+	_, err := d.addRule(ctx, "count_down", countdownParams(Rule{Enable: 1, Delay: uint(dur), Active: uint(boolToInt(target)), Name: name}), childID)
+	return err
+}
+
+// EditCountdownRule replaces the countdown with the same ID, Enable 0 pauses it
+func (d *Device) EditCountdownRule(r Rule) error {
+	return d.EditCountdownRuleCtx(context.Background(), r)
+}
+
+func (d *Device) EditCountdownRuleCtx(ctx context.Context, r Rule) error {
+	return d.editRule(ctx, "count_down", r.ID, countdownParams(r))
+}
+
+// EditCountdownRuleChild replaces the countdown with the same ID on one outlet of a multi-relay device
+func (d *Device) EditCountdownRuleChild(childID string, r Rule) error {
+	return d.EditCountdownRuleChildCtx(context.Background(), childID, r)
+}
+
+func (d *Device) EditCountdownRuleChildCtx(ctx context.Context, childID string, r Rule) error {
+	return d.editRule(ctx, "count_down", r.ID, countdownParams(r), childID)
+}
+
+// DeleteCountdownRule removes one countdown by ID
+func (d *Device) DeleteCountdownRule(id string) error {
+	return d.DeleteCountdownRuleCtx(context.Background(), id)
+}
+
+func (d *Device) DeleteCountdownRuleCtx(ctx context.Context, id string) error {
+	return d.deleteRule(ctx, "count_down", id)
+}
+
+// DeleteCountdownRuleChild removes one countdown by ID from one outlet of a multi-relay device
+func (d *Device) DeleteCountdownRuleChild(childID string, id string) error {
+	return d.DeleteCountdownRuleChildCtx(context.Background(), childID, id)
+}
+
+func (d *Device) DeleteCountdownRuleChildCtx(ctx context.Context, childID string, id string) error {
+	return d.deleteRule(ctx, "count_down", id, childID)
+}
+
+// countdownParams leaves out the read-only remain field
+func countdownParams(r Rule) Params {
+	p := Params{"enable": r.Enable, "delay": r.Delay, "act": r.Active, "name": r.Name}
+	if r.ID != "" {
+		p["id"] = r.ID
+	}
+	return p
 }
 
 func (d *Device) GetLightSensorConfig() (*LightSensorConfig, error) {
@@ -459,22 +453,11 @@ func (d *Device) GetLightSensorConfig() (*LightSensorConfig, error) {
 }
 
 func (d *Device) GetLightSensorConfigCtx(ctx context.Context) (*LightSensorConfig, error) {
-	res, err := d.sendTCP(ctx, CmdGetLightSensorConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	var ls LightSensor
-	if err = json.Unmarshal(res, &ls); err != nil {
-		return nil, err
-	}
-	if err := ls.GetConfig.OK(); err != nil {
+	var c LightSensorConfig
+	if err := d.call(ctx, NewRequest().Add("smartlife.iot.LAS", "get_config", nil), &c); err != nil {
 		return nil, err
 	}
-	if ls.GetConfig.Version == "" {
-		return nil, errors.New("light sensor module not present")
-	}
-	return &ls.GetConfig, nil
+	return &c, nil
 }
 
 func (d *Device) GetCurrentBrightness() (uint, error) {
@@ -482,28 +465,103 @@ func (d *Device) GetCurrentBrightness() (uint, error) {
 }
 
 func (d *Device) GetCurrentBrightnessCtx(ctx context.Context) (uint, error) {
-	res, err := d.sendTCP(ctx, CmdGetCurrentBrightness)
-	if err != nil {
+	var b LightSensorBrightness
+	if err := d.call(ctx, NewRequest().Add("smartlife.iot.LAS", "get_current_brt", nil), &b); err != nil {
 		return 0, err
 	}
+	return b.Value, nil
+}
 
-	var ls LightSensor
-	if err = json.Unmarshal(res, &ls); err != nil {
-		return 0, err
+// SetLightSensorEnable turns the ambient light sensor on or off
+func (d *Device) SetLightSensorEnable(enable bool) error {
+	return d.SetLightSensorEnableCtx(context.Background(), enable)
+}
+
+func (d *Device) SetLightSensorEnableCtx(ctx context.Context, enable bool) error {
+	return d.send(ctx, NewRequest().Add("smartlife.iot.LAS", "set_enable", Params{"enable": boolToInt(enable)}))
+}
+
+// SetLightSensorDarkIndex selects the level at which the device considers it dark, an index into LightSensorDev.Levels
+func (d *Device) SetLightSensorDarkIndex(index uint) error {
+	return d.SetLightSensorDarkIndexCtx(context.Background(), index)
+}
+
+func (d *Device) SetLightSensorDarkIndexCtx(ctx context.Context, index uint) error {
+	return d.send(ctx, NewRequest().Add("smartlife.iot.LAS", "set_dark_index", Params{"dark_index": index}))
+}
+
+// SetLightSensorDarkLevel selects the level at which the device considers it dark by name ("cloudy", "overcast"...)
+func (d *Device) SetLightSensorDarkLevel(name string) error {
+	return d.SetLightSensorDarkLevelCtx(context.Background(), name)
+}
+
+func (d *Device) SetLightSensorDarkLevelCtx(ctx context.Context, name string) error {
+	i, err := d.lightSensorLevel(ctx, name)
+	if err != nil {
+		return err
+	}
+	return d.SetLightSensorDarkIndexCtx(ctx, i)
+}
+
+// SetLightSensorLevel changes the brightness value of a named level ("cloudy", "overcast"...)
+func (d *Device) SetLightSensorLevel(name string, value uint) error {
+	return d.SetLightSensorLevelCtx(context.Background(), name, value)
+}
+
+func (d *Device) SetLightSensorLevelCtx(ctx context.Context, name string, value uint) error {
+	i, err := d.lightSensorLevel(ctx, name)
+	if err != nil {
+		return err
 	}
+	return d.send(ctx, NewRequest().Add("smartlife.iot.LAS", "set_brt_level", Params{"index": i, "value": value}))
+}
 
-	if err := ls.GetBrightness.OK(); err != nil {
+// lightSensorLevel looks up the index of a named level, the names come from the device
+func (d *Device) lightSensorLevel(ctx context.Context, name string) (uint, error) {
+	c, err := d.GetLightSensorConfigCtx(ctx)
+	if err != nil {
 		return 0, err
 	}
-	return ls.GetBrightness.Value, nil
-}
-
-/*
-   CmdSetBrightnessLevel   = `{"smartlife.iot.LAS":{"set_brt_level":{"index":%d,"value":%d}}}` // int, int
-   CmdSetDarkIndex         = `{"smartlife.iot.LAS":{"set_dark_index":{"dark_index":%d}}}`        // int
-   CmdSetLightSensorEnable = `{"smartlife.iot.LAS":{"set_enable":{"enable":%d}}}`               // 0/1
-   CmdGetPIRConfig         = `{"smartlife.iot.PIR":{"get_config":{}}}`
-   CmdSetPIRColdTime       = `{"smartlife.iot.PIR":{"set_cold_time":{"cold_time":%d}}}`          // int
-   CmdSetPIREnable         = `{"smartlife.iot.PIR":{"set_enable":{"enable":%d}}}`                // 0/1
-   CmdSetPIRSensitivity    = `{"smartlife.iot.PIR":{"set_trigger_sens":{"index":%d,"value":%d}}}` // int, int (~decimeters)
-*/
+	if len(c.Devs) == 0 {
+		return 0, fmt.Errorf("%w: no light sensor", ErrNoResponse)
+	}
+	i, ok := c.Devs[0].LevelIndex(name)
+	if !ok {
+		return 0, fmt.Errorf("unknown light sensor level %q", name)
+	}
+	return uint(i), nil
+}
+
+// GetEmeterGain returns the voltage and current calibration gains
+func (d *Device) GetEmeterGain() (*EmeterGain, error) {
+	return d.GetEmeterGainCtx(context.Background())
+}
+
+func (d *Device) GetEmeterGainCtx(ctx context.Context) (*EmeterGain, error) {
+	var g EmeterGain
+	if err := d.call(ctx, NewRequest().Add("emeter", "get_vgain_igain", nil), &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// SetEmeterGain writes new voltage and current calibration gains, see CalibratedGain
+func (d *Device) SetEmeterGain(vgain, igain int) error {
+	return d.SetEmeterGainCtx(context.Background(), vgain, igain)
+}
+
+func (d *Device) SetEmeterGainCtx(ctx context.Context, vgain, igain int) error {
+	if vgain <= 0 || igain <= 0 {
+		return fmt.Errorf("invalid gain %d/%d", vgain, igain)
+	}
+	return d.call(ctx, NewRequest().Add("emeter", "set_vgain_igain", Params{"vgain": vgain, "igain": igain}), nil)
+}
+
+// StartCalibration has the device calibrate itself against a known load, targets are in mV and mA
+func (d *Device) StartCalibration(vtarget, itarget uint) error {
+	return d.StartCalibrationCtx(context.Background(), vtarget, itarget)
+}
+
+func (d *Device) StartCalibrationCtx(ctx context.Context, vtarget, itarget uint) error {
+	return d.call(ctx, NewRequest().Add("emeter", "start_calibration", Params{"vtarget": vtarget, "itarget": itarget}), nil)
+}
@@ -23,11 +23,13 @@ func TestSetRelayStateCtx(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			md := &Device{
-				OverrideUDP: func(ctx context.Context, cmd string) error {
-					if tt.shouldErr {
-						return errors.New("udp error")
-					}
-					return nil
+				Transport: TransportFuncs{
+					SendFunc: func(ctx context.Context, addr string, cmd string) error {
+						if tt.shouldErr {
+							return errors.New("udp error")
+						}
+						return nil
+					},
 				},
 			}
 
@@ -83,8 +85,10 @@ func TestGetSettingsCtx(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			md := &Device{
-				OverrideTCP: func(ctx context.Context, cmd string) ([]byte, error) {
-					return []byte(tt.response), nil
+				Transport: TransportFuncs{
+					QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+						return []byte(tt.response), nil
+					},
 				},
 			}
 
@@ -147,8 +151,10 @@ func TestGetEmeterCtx(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			md := &Device{
-				OverrideTCP: func(ctx context.Context, cmd string) ([]byte, error) {
-					return []byte(tt.response), nil
+				Transport: TransportFuncs{
+					QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+						return []byte(tt.response), nil
+					},
 				},
 			}
 
@@ -168,6 +174,48 @@ func TestGetEmeterCtx(t *testing.T) {
 	}
 }
 
+func TestGetEmeterYearCtx(t *testing.T) {
+	var sent string
+	md := &Device{
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				sent = cmd
+				return []byte(`{"emeter":{"get_monthstat":{"month_list":[{"year":2025,"month":1,"energy_wh":25321},{"year":2025,"month":2,"energy_wh":21870}],"err_code":0}}}`), nil
+			},
+		},
+	}
+
+	res, err := md.GetEmeterChildYearCtx(context.Background(), 2025, "8006AB01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"context":{"child_ids":["8006AB01"]},"emeter":{"get_monthstat":{"year":2025}}}`; sent != want {
+		t.Fatalf("sent %s, want %s", sent, want)
+	}
+	if len(res.List) != 2 || res.Total() != 47191 {
+		t.Fatalf("got %+v", res)
+	}
+}
+
+func TestEraseEmeterStatsCtx(t *testing.T) {
+	var sent string
+	md := &Device{
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				sent = cmd
+				return []byte(`{"emeter":{"erase_emeter_stat":{"err_code":0}}}`), nil
+			},
+		},
+	}
+
+	if err := md.EraseEmeterStatsCtx(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sent != CmdEmeterErase {
+		t.Fatalf("sent %s, want %s", sent, CmdEmeterErase)
+	}
+}
+
 func TestSetRelayStateChildMultiCtx(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -189,13 +237,15 @@ func TestSetRelayStateChildMultiCtx(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			md := &Device{
-				OverrideUDP: func(ctx context.Context, cmd string) error {
-					for _, c := range tt.children {
-						if !strings.Contains(cmd, c) {
-							t.Fatalf("expected child %q in cmd %q", c, cmd)
+				Transport: TransportFuncs{
+					SendFunc: func(ctx context.Context, addr string, cmd string) error {
+						for _, c := range tt.children {
+							if !strings.Contains(cmd, c) {
+								t.Fatalf("expected child %q in cmd %q", c, cmd)
+							}
 						}
-					}
-					return nil
+						return nil
+					},
 				},
 			}
 
@@ -209,11 +259,13 @@ func TestSetRelayStateChildMultiCtx(t *testing.T) {
 
 func TestSendRawCommandCtx(t *testing.T) {
 	md := &Device{
-		OverrideTCP: func(ctx context.Context, cmd string) ([]byte, error) {
-			return []byte(`ok`), nil
-		},
-		OverrideUDP: func(ctx context.Context, cmd string) error {
-			return nil
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				return []byte(`ok`), nil
+			},
+			SendFunc: func(ctx context.Context, addr string, cmd string) error {
+				return nil
+			},
 		},
 	}
 
@@ -222,3 +274,89 @@ func TestSendRawCommandCtx(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestEmeterCalibration(t *testing.T) {
+	tests := []struct {
+		name      string
+		gain      int
+		reading   float64
+		reference float64
+		want      int
+	}{
+		{"reads 3% high", 13462, 123.6, 120.0, 13070},
+		{"reads low", 16835, 0.97, 1.0, 17356},
+		{"no reading", 13462, 0, 120.0, 13462},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalibratedGain(tt.gain, tt.reading, tt.reference); got != tt.want {
+				t.Fatalf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	var sent []string
+	md := &Device{
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				sent = append(sent, cmd)
+				if strings.Contains(cmd, "start_calibration") {
+					return []byte(`{"emeter":{"start_calibration":{"err_code":0}}}`), nil
+				}
+				return []byte(`{"emeter":{"set_vgain_igain":{"err_code":0}}}`), nil
+			},
+		},
+	}
+	if err := md.SetEmeterGainCtx(context.Background(), 13070, 17356); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := md.StartCalibrationCtx(context.Background(), 120000, 1000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		`{"emeter":{"set_vgain_igain":{"igain":17356,"vgain":13070}}}`,
+		`{"emeter":{"start_calibration":{"itarget":1000,"vtarget":120000}}}`,
+	}
+	if len(sent) != 2 || sent[0] != want[0] || sent[1] != want[1] {
+		t.Fatalf("sent %v, want %v", sent, want)
+	}
+}
+
+func TestEmeterRealtimeFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		volts    float64
+		amps     float64
+		watts    float64
+		kwh      float64
+	}{
+		{
+			"milli units",
+			`{"emeter":{"get_realtime":{"current_ma":1799,"voltage_mv":121882,"power_mw":174545,"total_wh":547,"err_code":0}}}`,
+			121.882, 1.799, 174.545, 0.547,
+		},
+		{
+			"hs110 v1 floats",
+			`{"emeter":{"get_realtime":{"current":1.799,"voltage":121.882,"power":174.545,"total":0.547,"err_code":0}}}`,
+			121.882, 1.799, 174.545, 0.547,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em, err := mockQuery(tt.response).GetEmeterCtx(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if em.Volts() != tt.volts || em.Amps() != tt.amps || em.Watts() != tt.watts || em.KWh() != tt.kwh {
+				t.Fatalf("got %vV %vA %vW %vkWh", em.Volts(), em.Amps(), em.Watts(), em.KWh())
+			}
+		})
+	}
+
+	// errors still come through the custom decoder
+	if _, err := mockQuery(`{"emeter":{"get_realtime":{"err_code":-1,"err_msg":"module not support"}}}`).GetEmeterCtx(context.Background()); !errors.Is(err, ErrModuleNotSupported) {
+		t.Fatalf("expected module not supported, got %v", err)
+	}
+}
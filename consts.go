@@ -3,7 +3,8 @@ package kasa
 // https://lib.dr.iastate.edu/cgi/viewcontent.cgi?article=1424&context=creativecomponents
 // https://github.com/whitslack/kasa/blob/master/API.md
 
-// Request strings
+// Request strings, kept for raw commands and compatibility.
+// The Device methods build their commands with NewRequest so that string arguments are escaped.
 const (
 	CmdSetRelayState = `{"system":{"set_relay_state":{"state":%d}}}` // 0 or 1
 	CmdGetSysinfo    = `{"system":{"get_sysinfo":{}}}`
@@ -42,10 +43,8 @@ const (
 	CmdDeleteAllRules    = `{"count_down":{"delete_all_rules":{}}}`
 	CmdAddCountdownRule  = `{"count_down":{"add_rule":{"enable":1,"delay":%d,"act":%d,"name":"%s"}}}` // 0-3600, 0/1, string
 
-	// CmdGetRules = `{"smartlife.iot.common.schedule":{"get_rules":{}}}`
-	// CmdGetCountdownRules = `{"smartlife.iot.common.count_down":{"get_rules":{}}}`
-	// CmdDeleteAllRules    = `{"smartlife.iot.common.count_down":{"delete_all_rules":{}}}`
-	// CmdAddCountdownRule  = `{"smartlife.iot.common.count_down":{"add_rule":{"enable":1,"delay":%d,"act":%d,"name":"%s"}}}`
+	// dimmers and bulbs use smartlife.iot.common.count_down and smartlife.iot.common.schedule,
+	// the rule methods pick the namespace from the device's capabilities
 
 	CmdCloudUnbind    = `{"cnCloud":{"unbind":null}}`
 	CmdSetServerURL   = `{"cnCloud":{"set_server_url":{"server":"%s"}}}`          // bare hostname, no protocol spec
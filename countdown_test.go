@@ -0,0 +1,81 @@
+package kasa
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountdownRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  string
+		module string
+		call   func(d *Device) error
+		want   string
+	}{
+		{
+			"plug add",
+			"HS103(US)",
+			"count_down",
+			func(d *Device) error { return d.AddCountdownRuleCtx(context.Background(), 60, true, "fan") },
+			`{"count_down":{"add_rule":{"act":1,"delay":60,"enable":1,"name":"fan"}}}`,
+		},
+		{
+			"dimmer add",
+			"HS220(US)",
+			"smartlife.iot.common.count_down",
+			func(d *Device) error { return d.AddCountdownRuleCtx(context.Background(), 60, false, "lamp") },
+			`{"smartlife.iot.common.count_down":{"add_rule":{"act":0,"delay":60,"enable":1,"name":"lamp"}}}`,
+		},
+		{
+			"strip child add",
+			"HS300(US)",
+			"count_down",
+			func(d *Device) error {
+				return d.AddCountdownRuleChildCtx(context.Background(), "8006AB01", 30, false, "heater")
+			},
+			`{"context":{"child_ids":["8006AB01"]},"count_down":{"add_rule":{"act":0,"delay":30,"enable":1,"name":"heater"}}}`,
+		},
+		{
+			"edit",
+			"HS103(US)",
+			"count_down",
+			func(d *Device) error {
+				return d.EditCountdownRuleCtx(context.Background(), Rule{ID: "C1", Name: "fan", Enable: 0, Delay: 120, Active: 1, Remaining: 50})
+			},
+			`{"count_down":{"edit_rule":{"act":1,"delay":120,"enable":0,"id":"C1","name":"fan"}}}`,
+		},
+		{
+			"child delete",
+			"HS300(US)",
+			"count_down",
+			func(d *Device) error { return d.DeleteCountdownRuleChildCtx(context.Background(), "8006AB02", "C2") },
+			`{"context":{"child_ids":["8006AB02"]},"count_down":{"delete_rule":{"id":"C2"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			d := ruleDevice(tt.model, `{"`+tt.module+`":{"add_rule":{"id":"NEW","err_code":0},"edit_rule":{"err_code":0},"delete_rule":{"err_code":0}}}`, &sent)
+
+			if err := tt.call(d); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(sent) != 1 || sent[0] != tt.want {
+				t.Fatalf("sent %v, want %s", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteCountdownRuleNoID(t *testing.T) {
+	var sent []string
+	d := ruleDevice("HS103(US)", `{"count_down":{"delete_rule":{"err_code":0}}}`, &sent)
+	if err := d.DeleteCountdownRuleCtx(context.Background(), ""); err == nil {
+		t.Fatal("expected an error for an empty ID")
+	}
+	if len(sent) != 0 {
+		t.Fatalf("sent %v", sent)
+	}
+}
@@ -0,0 +1,201 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ButtonAction is what a double click or long press on a dimmer's paddle does
+type ButtonAction string
+
+const (
+	ActionNone         ButtonAction = "none"
+	ActionInstantOnOff ButtonAction = "instant_on_off"
+	ActionGentleOnOff  ButtonAction = "gentle_on_off"
+	ActionPreset       ButtonAction = "customize_preset"
+)
+
+// ParseButtonAction accepts none, instant, gentle or preset
+func ParseButtonAction(s string) (ButtonAction, error) {
+	switch strings.ToLower(s) {
+	case "none", "":
+		return ActionNone, nil
+	case "instant", string(ActionInstantOnOff):
+		return ActionInstantOnOff, nil
+	case "gentle", string(ActionGentleOnOff):
+		return ActionGentleOnOff, nil
+	case "preset", string(ActionPreset):
+		return ActionPreset, nil
+	}
+	return ActionNone, fmt.Errorf("unknown action %q, want none, instant, gentle or preset", s)
+}
+
+// BehaviorMode is how a dimmer comes on
+type BehaviorMode string
+
+const (
+	BehaviorLastStatus BehaviorMode = "last_status"
+	BehaviorPreset     BehaviorMode = "customize_preset"
+)
+
+// Behavior is one entry of get_default_behavior, Index is the preset when Mode is a preset
+type Behavior struct {
+	Mode  string `json:"mode"`
+	Index *int   `json:"index,omitempty"`
+}
+
+// DefaultBehavior is the result of get_default_behavior.
+// HardOn is power returning or the paddle, SoftOn is the app or a schedule
+type DefaultBehavior struct {
+	HardOn      Behavior `json:"hard_on"`
+	SoftOn      Behavior `json:"soft_on"`
+	LongPress   Behavior `json:"long_press"`
+	DoubleClick Behavior `json:"double_click"`
+	KasaErr
+}
+
+const (
+	minRampRate  = 10
+	maxRampRate  = 50
+	maxThreshold = 51
+	maxPreset    = 3
+)
+
+func checkPreset(index int) error {
+	if index < 0 || index > maxPreset {
+		return fmt.Errorf("preset must be 0-%d, got %d", maxPreset, index)
+	}
+	return nil
+}
+
+// SetDimmerTransition fades to brightness (1-100) over dur
+func (d *Device) SetDimmerTransition(brightness int, dur time.Duration) error {
+	return d.SetDimmerTransitionCtx(context.Background(), brightness, dur)
+}
+
+func (d *Device) SetDimmerTransitionCtx(ctx context.Context, brightness int, dur time.Duration) error {
+	if brightness < 1 || brightness > 100 {
+		return fmt.Errorf("brightness must be 1-100, got %d", brightness)
+	}
+	if dur < time.Millisecond {
+		return fmt.Errorf("transition must be at least 1ms, got %s", dur)
+	}
+	r := NewRequest().Add("smartlife.iot.dimmer", "set_dimmer_transition", Params{"brightness": brightness, "duration": int(dur / time.Millisecond)})
+	return d.send(ctx, r)
+}
+
+// SetRampRate sets how fast holding the paddle changes the brightness (10-50)
+func (d *Device) SetRampRate(rate int) error {
+	return d.SetRampRateCtx(context.Background(), rate)
+}
+
+func (d *Device) SetRampRateCtx(ctx context.Context, rate int) error {
+	if rate < minRampRate || rate > maxRampRate {
+		return fmt.Errorf("ramp rate must be %d-%d, got %d", minRampRate, maxRampRate, rate)
+	}
+	r := NewRequest().Add("smartlife.iot.dimmer", "set_ramp_rate", Params{"rampRate": rate})
+	return d.send(ctx, r)
+}
+
+// SetMinThreshold sets the lowest output level (0-51), raise it if bulbs flicker when dim
+func (d *Device) SetMinThreshold(threshold int) error {
+	return d.SetMinThresholdCtx(context.Background(), threshold)
+}
+
+func (d *Device) SetMinThresholdCtx(ctx context.Context, threshold int) error {
+	if threshold < 0 || threshold > maxThreshold {
+		return fmt.Errorf("threshold must be 0-%d, got %d", maxThreshold, threshold)
+	}
+	r := NewRequest().Add("smartlife.iot.dimmer", "set_threshold_min", Params{"minThreshold": threshold})
+	return d.send(ctx, r)
+}
+
+// buttonParams validates an action, preset is only sent for ActionPreset
+func buttonParams(action ButtonAction, preset int) (Params, error) {
+	switch action {
+	case ActionNone, ActionInstantOnOff, ActionGentleOnOff, ActionPreset:
+	default:
+		return nil, fmt.Errorf("unknown action %q", action)
+	}
+	p := Params{"mode": string(action)}
+	if action == ActionPreset {
+		if err := checkPreset(preset); err != nil {
+			return nil, err
+		}
+		p["index"] = preset
+	}
+	return p, nil
+}
+
+// SetDoubleClickAction sets what a double click does, preset is used with ActionPreset
+func (d *Device) SetDoubleClickAction(action ButtonAction, preset int) error {
+	return d.SetDoubleClickActionCtx(context.Background(), action, preset)
+}
+
+func (d *Device) SetDoubleClickActionCtx(ctx context.Context, action ButtonAction, preset int) error {
+	p, err := buttonParams(action, preset)
+	if err != nil {
+		return err
+	}
+	return d.send(ctx, NewRequest().Add("smartlife.iot.dimmer", "set_double_click_action", p))
+}
+
+// SetLongPressAction sets what holding the paddle does, preset is used with ActionPreset
+func (d *Device) SetLongPressAction(action ButtonAction, preset int) error {
+	return d.SetLongPressActionCtx(context.Background(), action, preset)
+}
+
+func (d *Device) SetLongPressActionCtx(ctx context.Context, action ButtonAction, preset int) error {
+	p, err := buttonParams(action, preset)
+	if err != nil {
+		return err
+	}
+	return d.send(ctx, NewRequest().Add("smartlife.iot.dimmer", "set_long_press_action", p))
+}
+
+// GetDefaultBehavior returns how the dimmer comes on and what its paddle gestures do
+func (d *Device) GetDefaultBehavior() (*DefaultBehavior, error) {
+	return d.GetDefaultBehaviorCtx(context.Background())
+}
+
+func (d *Device) GetDefaultBehaviorCtx(ctx context.Context) (*DefaultBehavior, error) {
+	var b DefaultBehavior
+	if err := d.call(ctx, NewRequest().Add("smartlife.iot.dimmer", "get_default_behavior", nil), &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// SetDefaultBehavior sets how the dimmer comes on; kind is "hard_on" (power or paddle) or "soft_on" (app or schedule)
+func (d *Device) SetDefaultBehavior(kind string, mode BehaviorMode, preset int) error {
+	return d.SetDefaultBehaviorCtx(context.Background(), kind, mode, preset)
+}
+
+func (d *Device) SetDefaultBehaviorCtx(ctx context.Context, kind string, mode BehaviorMode, preset int) error {
+	if kind != "hard_on" && kind != "soft_on" {
+		return fmt.Errorf("unknown behavior %q, want hard_on or soft_on", kind)
+	}
+	b := Params{"mode": string(mode)}
+	switch mode {
+	case BehaviorLastStatus:
+	case BehaviorPreset:
+		if err := checkPreset(preset); err != nil {
+			return err
+		}
+		b["index"] = preset
+	default:
+		return fmt.Errorf("unknown mode %q, want %s or %s", mode, BehaviorLastStatus, BehaviorPreset)
+	}
+	return d.send(ctx, NewRequest().Add("smartlife.iot.dimmer", "set_default_behavior", Params{kind: b}))
+}
+
+// CalibrateBrightness has the dimmer measure the attached load to find its usable range, the lights will flicker
+func (d *Device) CalibrateBrightness() error {
+	return d.CalibrateBrightnessCtx(context.Background())
+}
+
+func (d *Device) CalibrateBrightnessCtx(ctx context.Context) error {
+	return d.call(ctx, NewRequest().Add("smartlife.iot.dimmer", "calibrate_brightness", nil), nil)
+}
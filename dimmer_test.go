@@ -0,0 +1,80 @@
+package kasa
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDimmerSetters(t *testing.T) {
+	tests := []struct {
+		name      string
+		call      func(d *Device) error
+		want      string
+		shouldErr bool
+	}{
+		{"transition", func(d *Device) error { return d.SetDimmerTransitionCtx(context.Background(), 60, 3*time.Second) }, `{"smartlife.iot.dimmer":{"set_dimmer_transition":{"brightness":60,"duration":3000}}}`, false},
+		{"transition brightness", func(d *Device) error { return d.SetDimmerTransitionCtx(context.Background(), 101, time.Second) }, "", true},
+		{"transition duration", func(d *Device) error { return d.SetDimmerTransitionCtx(context.Background(), 50, 0) }, "", true},
+		{"ramp rate", func(d *Device) error { return d.SetRampRateCtx(context.Background(), 30) }, `{"smartlife.iot.dimmer":{"set_ramp_rate":{"rampRate":30}}}`, false},
+		{"ramp rate range", func(d *Device) error { return d.SetRampRateCtx(context.Background(), 5) }, "", true},
+		{"threshold", func(d *Device) error { return d.SetMinThresholdCtx(context.Background(), 12) }, `{"smartlife.iot.dimmer":{"set_threshold_min":{"minThreshold":12}}}`, false},
+		{"threshold range", func(d *Device) error { return d.SetMinThresholdCtx(context.Background(), 52) }, "", true},
+		{"double click", func(d *Device) error { return d.SetDoubleClickActionCtx(context.Background(), ActionGentleOnOff, 2) }, `{"smartlife.iot.dimmer":{"set_double_click_action":{"mode":"gentle_on_off"}}}`, false},
+		{"long press preset", func(d *Device) error { return d.SetLongPressActionCtx(context.Background(), ActionPreset, 2) }, `{"smartlife.iot.dimmer":{"set_long_press_action":{"index":2,"mode":"customize_preset"}}}`, false},
+		{"bad preset", func(d *Device) error { return d.SetLongPressActionCtx(context.Background(), ActionPreset, 4) }, "", true},
+		{"bad action", func(d *Device) error { return d.SetDoubleClickActionCtx(context.Background(), "triple", 0) }, "", true},
+		{"hard on", func(d *Device) error {
+			return d.SetDefaultBehaviorCtx(context.Background(), "hard_on", BehaviorPreset, 1)
+		}, `{"smartlife.iot.dimmer":{"set_default_behavior":{"hard_on":{"index":1,"mode":"customize_preset"}}}}`, false},
+		{"soft on", func(d *Device) error {
+			return d.SetDefaultBehaviorCtx(context.Background(), "soft_on", BehaviorLastStatus, 0)
+		}, `{"smartlife.iot.dimmer":{"set_default_behavior":{"soft_on":{"mode":"last_status"}}}}`, false},
+		{"bad behavior", func(d *Device) error {
+			return d.SetDefaultBehaviorCtx(context.Background(), "warm_on", BehaviorLastStatus, 0)
+		}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent string
+			d := &Device{
+				Transport: TransportFuncs{
+					SendFunc: func(ctx context.Context, addr string, cmd string) error {
+						sent = cmd
+						return nil
+					},
+				},
+			}
+
+			err := tt.call(d)
+			if tt.shouldErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if sent != "" {
+					t.Fatalf("sent %s after a failed check", sent)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sent != tt.want {
+				t.Fatalf("sent %s, want %s", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDefaultBehavior(t *testing.T) {
+	d := mockQuery(`{"smartlife.iot.dimmer":{"get_default_behavior":{"hard_on":{"mode":"last_status"},"soft_on":{"mode":"customize_preset","index":1},"long_press":{"mode":"instant_on_off"},"double_click":{"mode":"none"},"err_code":0}}}`)
+
+	b, err := d.GetDefaultBehaviorCtx(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.HardOn.Mode != string(BehaviorLastStatus) || b.SoftOn.Index == nil || *b.SoftOn.Index != 1 || b.LongPress.Mode != string(ActionInstantOnOff) {
+		t.Fatalf("got %+v", b)
+	}
+}
@@ -1,9 +1,9 @@
 package kasa
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net"
 	"time"
 )
@@ -15,11 +15,6 @@ func BroadcastDiscovery(ctx context.Context, probes int) (map[string]*Sysinfo, e
 	result := make(map[string]*Sysinfo)
 
 	err := discover(ctx, probes, CmdGetSysinfo, func(addr *net.UDPAddr, kd *KasaDevice) error {
-		if err := kd.GetSysinfo.Sysinfo.KasaErr.OK(); err != nil {
-			klogger.Println(err)
-			return nil
-		}
-
 		info := kd.GetSysinfo.Sysinfo
 		result[addr.IP.String()] = &info
 		return nil
@@ -33,10 +28,6 @@ func BroadcastDimmerParameters(ctx context.Context, probes int) (map[string]*Dim
 	result := make(map[string]*DimmerParameters)
 
 	err := discover(ctx, probes, CmdGetDimmer, func(addr *net.UDPAddr, kd *KasaDevice) error {
-		if err := kd.Dimmer.KasaErr.OK(); err != nil {
-			klogger.Println(err)
-			return nil
-		}
 		dimmer := kd.Dimmer.Parameters
 		result[addr.IP.String()] = &dimmer
 		return nil
@@ -50,10 +41,6 @@ func BroadcastWifiParameters(ctx context.Context, probes int) (map[string]*StaIn
 	result := make(map[string]*StaInfo)
 
 	err := discover(ctx, probes, CmdWifiStainfo, func(addr *net.UDPAddr, kd *KasaDevice) error {
-		if err := kd.NetIf.KasaErr.OK(); err != nil {
-			klogger.Println(err)
-			return nil
-		}
 		stainfo := kd.NetIf.StaInfo
 		result[addr.IP.String()] = &stainfo
 		return nil
@@ -66,15 +53,6 @@ func BroadcastEmeter(ctx context.Context, probes int) (map[string]*KasaDevice, e
 	result := make(map[string]*KasaDevice)
 
 	err := discover(ctx, probes, CmdGetEmeter, func(addr *net.UDPAddr, kd *KasaDevice) error {
-		if err := kd.Emeter.KasaErr.OK(); err != nil {
-			klogger.Println(err)
-			return nil
-		}
-		if err := kd.Emeter.Realtime.KasaErr.OK(); err != nil {
-			klogger.Println(err)
-			return nil
-		}
-
 		device := *kd
 		result[addr.IP.String()] = &device
 		return nil
@@ -114,7 +92,7 @@ func discover(ctx context.Context, probes int, cmd string, handler func(addr *ne
 	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: nil, Port: 0})
 	if err != nil {
 		klogger.Printf("unable to start listener: %s", err.Error())
-		return err
+		return &TransportError{Op: "listen", Err: err}
 	}
 	defer conn.Close()
 
@@ -146,11 +124,22 @@ func discover(ctx context.Context, probes int, cmd string, handler func(addr *ne
 			if ne, ok := err.(net.Error); ok && ne.Timeout() {
 				continue
 			}
-			return err
+			return &TransportError{Op: "read", Err: err}
 		}
 
 		res := Unscramble(buffer[:n])
-		if bytes.Contains(res, []byte("module not support")) {
+		errs, err := responseErrors(res)
+		if err != nil {
+			klogger.Println(err)
+			continue
+		}
+		if len(errs) > 0 {
+			// devices without the module answer "module not support", they aren't interesting
+			for _, err := range errs {
+				if !errors.Is(err, ErrModuleNotSupported) {
+					klogger.Println(err)
+				}
+			}
 			continue
 		}
 
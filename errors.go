@@ -0,0 +1,96 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Sentinel errors, use errors.Is to match them against anything returned by a Device method
+var (
+	// ErrDevice matches every error reported by the device itself
+	ErrDevice = errors.New("kasa: device error")
+	// ErrModuleNotSupported is err_code -1, the device doesn't have the module (e.g. emeter on an HS200)
+	ErrModuleNotSupported = errors.New("kasa: module not supported")
+	// ErrMethodNotSupported is err_code -2, the module exists but the method doesn't
+	ErrMethodNotSupported = errors.New("kasa: method not supported")
+	// ErrInvalidArgument is err_code -3, the parameters were rejected
+	ErrInvalidArgument = errors.New("kasa: invalid argument")
+	// ErrNoResponse is returned when the reply is missing the requested module or method
+	ErrNoResponse = errors.New("kasa: no response")
+	// ErrDownloadFailed is returned when a firmware download reports an error or stops before it is complete
+	ErrDownloadFailed = errors.New("kasa: firmware download failed")
+
+	// ErrTransport matches every failure to reach the device or read its reply
+	ErrTransport = errors.New("kasa: transport error")
+	// ErrTimeout matches transport failures caused by a timeout or deadline
+	ErrTimeout = errors.New("kasa: timeout")
+)
+
+// Is lets errors.Is match a ResponseError against ErrDevice and the err_code sentinels
+func (e *ResponseError) Is(target error) bool {
+	switch target {
+	case ErrDevice:
+		return true
+	case ErrModuleNotSupported:
+		return e.ErrCode == -1
+	case ErrMethodNotSupported:
+		return e.ErrCode == -2
+	case ErrInvalidArgument:
+		return e.ErrCode == -3
+	}
+	return false
+}
+
+// TransportError is a failure to reach the device or read its reply, as opposed to an error reported by the device
+type TransportError struct {
+	Op   string // "query", "send", "listen", "read"
+	Addr string
+	Err  error
+}
+
+func (e *TransportError) Error() string {
+	if e.Addr == "" {
+		return fmt.Sprintf("kasa %s: %s", e.Op, e.Err.Error())
+	}
+	return fmt.Sprintf("kasa %s %s: %s", e.Op, e.Addr, e.Err.Error())
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports if the failure was caused by a timeout or an expired context
+func (e *TransportError) Timeout() bool {
+	var ne net.Error
+	if errors.As(e.Err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// Is lets errors.Is match a TransportError against ErrTransport and ErrTimeout
+func (e *TransportError) Is(target error) bool {
+	switch target {
+	case ErrTransport:
+		return true
+	case ErrTimeout:
+		return e.Timeout()
+	}
+	return false
+}
+
+// transportErr wraps err in a TransportError unless it is already typed
+func transportErr(op, addr string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var re *ResponseError
+	var te *TransportError
+	if errors.As(err, &re) || errors.As(err, &te) {
+		return err
+	}
+	return &TransportError{Op: op, Addr: addr, Err: err}
+}
@@ -0,0 +1,122 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestErrorMatching(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		queryErr  error
+		matches   []error
+		unmatched []error
+	}{
+		{
+			name:      "module not supported",
+			response:  `{"emeter":{"err_code":-1,"err_msg":"module not support"}}`,
+			matches:   []error{ErrDevice, ErrModuleNotSupported},
+			unmatched: []error{ErrMethodNotSupported, ErrTransport},
+		},
+		{
+			name:      "method not supported",
+			response:  `{"emeter":{"get_realtime":{"err_code":-2,"err_msg":"member not support"}}}`,
+			matches:   []error{ErrDevice, ErrMethodNotSupported},
+			unmatched: []error{ErrModuleNotSupported, ErrTimeout},
+		},
+		{
+			name:      "invalid argument",
+			response:  `{"emeter":{"get_realtime":{"err_code":-3,"err_msg":"invalid argument"}}}`,
+			matches:   []error{ErrDevice, ErrInvalidArgument},
+			unmatched: []error{ErrTransport},
+		},
+		{
+			name:      "missing section",
+			response:  `{"system":{"get_sysinfo":{"err_code":0}}}`,
+			matches:   []error{ErrNoResponse},
+			unmatched: []error{ErrDevice, ErrTransport},
+		},
+		{
+			name:      "transport failure",
+			queryErr:  errors.New("connection refused"),
+			matches:   []error{ErrTransport},
+			unmatched: []error{ErrDevice, ErrTimeout},
+		},
+		{
+			name:      "transport timeout",
+			queryErr:  context.DeadlineExceeded,
+			matches:   []error{ErrTransport, ErrTimeout, context.DeadlineExceeded},
+			unmatched: []error{ErrDevice},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := &Device{
+				Transport: TransportFuncs{
+					QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+						if tt.queryErr != nil {
+							return nil, tt.queryErr
+						}
+						return []byte(tt.response), nil
+					},
+				},
+			}
+
+			_, err := md.GetEmeterCtx(context.Background())
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			for _, target := range tt.matches {
+				if !errors.Is(err, target) {
+					t.Errorf("expected %v to match %v", err, target)
+				}
+			}
+			for _, target := range tt.unmatched {
+				if errors.Is(err, target) {
+					t.Errorf("expected %v not to match %v", err, target)
+				}
+			}
+		})
+	}
+}
+
+func TestKasaErrOK(t *testing.T) {
+	if err := (KasaErr{}).OK(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := KasaErr{ErrCode: -1, ErrMsg: "module not support"}.OK()
+	if !errors.Is(err, ErrModuleNotSupported) {
+		t.Fatalf("expected module not supported, got %v", err)
+	}
+
+	var re *ResponseError
+	if !errors.As(err, &re) || re.ErrCode != -1 {
+		t.Fatalf("expected *ResponseError, got %T", err)
+	}
+}
+
+func TestRetrySkipsDeviceErrors(t *testing.T) {
+	calls := 0
+	base := TransportFuncs{
+		SendFunc: func(ctx context.Context, addr string, cmd string) error {
+			calls++
+			return &ResponseError{Module: "system", Method: "set_relay_state", KasaErr: KasaErr{ErrCode: -3}}
+		},
+	}
+
+	md := &Device{Transport: Chain(base, RetryMiddleware(3, 0))}
+	err := md.SetRelayStateCtx(context.Background(), true)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+	if errors.Is(err, ErrTransport) {
+		t.Fatalf("device error wrapped as transport error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d calls, want 1", calls)
+	}
+}
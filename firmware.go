@@ -0,0 +1,180 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Firmware is an update the cloud offers for the device
+type Firmware struct {
+	Type        int    `json:"fwType"`
+	Version     string `json:"fwVer"`
+	URL         string `json:"fwUrl"`
+	Title       string `json:"fwTitle"`
+	ReleaseDate string `json:"fwReleaseDate"`
+	ReleaseLog  string `json:"fwReleaseLog"`
+}
+
+// FirmwareList is the result of get_intl_fw_list
+type FirmwareList struct {
+	List []Firmware `json:"fw_list"`
+	KasaErr
+}
+
+// DownloadState is the progress of a firmware download, Ratio is percent complete
+type DownloadState struct {
+	Status      int `json:"status"`
+	Ratio       int `json:"ratio"`
+	RebootTime  int `json:"reboot_time"`
+	UpgradeTime int `json:"upgrade_time"`
+	FlashTime   int `json:"flash_time"`
+	KasaErr
+}
+
+// DownloadState.Status values, negative values are errors
+const (
+	DownloadIdle     = 0
+	DownloadRunning  = 1
+	DownloadComplete = 2
+)
+
+// maxIdlePolls is how many polls a download may stay idle before it is considered not to have started
+const maxIdlePolls = 5
+
+// Done reports whether the whole image has been downloaded
+func (s *DownloadState) Done() bool {
+	return !s.Failed() && (s.Status == DownloadComplete || (s.Status == DownloadRunning && s.Ratio >= 100))
+}
+
+// Failed reports whether the device gave up on the download
+func (s *DownloadState) Failed() bool {
+	return s.Status < 0
+}
+
+// cloudModule is "cnCloud" everywhere but on bulbs
+func (d *Device) cloudModule(ctx context.Context) (string, error) {
+	c, err := d.CapabilitiesCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+	if c.Has(CapBulb) {
+		return "smartlife.iot.common.cloud", nil
+	}
+	return "cnCloud", nil
+}
+
+// GetFirmwareList asks the cloud for available updates, the device must be bound to the cloud
+func (d *Device) GetFirmwareList() (*FirmwareList, error) {
+	return d.GetFirmwareListCtx(context.Background())
+}
+
+func (d *Device) GetFirmwareListCtx(ctx context.Context) (*FirmwareList, error) {
+	m, err := d.cloudModule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var l FirmwareList
+	if err := d.call(ctx, NewRequest().Add(m, "get_intl_fw_list", nil), &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// DownloadFirmware has the device fetch a firmware image, the device only speaks plain http
+func (d *Device) DownloadFirmware(fwurl string) error {
+	return d.DownloadFirmwareCtx(context.Background(), fwurl)
+}
+
+func (d *Device) DownloadFirmwareCtx(ctx context.Context, fwurl string) error {
+	u, err := url.Parse(fwurl)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" || u.Host == "" {
+		return fmt.Errorf("firmware url must be http://host/path, got %q", fwurl)
+	}
+	return d.call(ctx, NewRequest().Add("system", "download_firmware", Params{"url": fwurl}), nil)
+}
+
+// GetDownloadState returns the progress of a firmware download
+func (d *Device) GetDownloadState() (*DownloadState, error) {
+	return d.GetDownloadStateCtx(context.Background())
+}
+
+func (d *Device) GetDownloadStateCtx(ctx context.Context) (*DownloadState, error) {
+	var s DownloadState
+	if err := d.call(ctx, NewRequest().Add("system", "get_download_state", nil), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// WaitForDownload polls the download state every interval until it is done; progress, if set, sees every poll.
+// It returns ErrDownloadFailed if the device reports an error or goes idle without finishing.
+// It does not time out on its own, so pass a context with a deadline in case the download stalls.
+func (d *Device) WaitForDownload(interval time.Duration, progress func(*DownloadState)) (*DownloadState, error) {
+	return d.WaitForDownloadCtx(context.Background(), interval, progress)
+}
+
+func (d *Device) WaitForDownloadCtx(ctx context.Context, interval time.Duration, progress func(*DownloadState)) (*DownloadState, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	started, idle := false, 0
+	for {
+		s, err := d.GetDownloadStateCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(s)
+		}
+
+		switch {
+		case s.Done():
+			return s, nil
+		case s.Failed():
+			return s, fmt.Errorf("%w: status %d at %d%%", ErrDownloadFailed, s.Status, s.Ratio)
+		case s.Status == DownloadIdle:
+			// the device may not have started yet, but idle after running means it gave up
+			idle++
+			if started || idle >= maxIdlePolls {
+				return s, fmt.Errorf("%w: idle at %d%%", ErrDownloadFailed, s.Ratio)
+			}
+		default:
+			started = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return s, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// FlashFirmware writes the downloaded image and reboots the device
+func (d *Device) FlashFirmware() error {
+	return d.FlashFirmwareCtx(context.Background())
+}
+
+func (d *Device) FlashFirmwareCtx(ctx context.Context) error {
+	return d.call(ctx, NewRequest().Add("system", "flash_firmware", nil), nil)
+}
+
+// UpdateFirmware downloads the image at fwurl, polls every interval until it has arrived, then flashes it
+func (d *Device) UpdateFirmware(fwurl string, interval time.Duration, progress func(*DownloadState)) error {
+	return d.UpdateFirmwareCtx(context.Background(), fwurl, interval, progress)
+}
+
+func (d *Device) UpdateFirmwareCtx(ctx context.Context, fwurl string, interval time.Duration, progress func(*DownloadState)) error {
+	if err := d.DownloadFirmwareCtx(ctx, fwurl); err != nil {
+		return err
+	}
+	if _, err := d.WaitForDownloadCtx(ctx, interval, progress); err != nil {
+		return err
+	}
+	return d.FlashFirmwareCtx(ctx)
+}
@@ -0,0 +1,120 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetFirmwareList(t *testing.T) {
+	tests := []struct {
+		model  string
+		module string
+	}{
+		{"HS103(US)", "cnCloud"},
+		{"KL130(US)", "smartlife.iot.common.cloud"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			var sent []string
+			d := ruleDevice(tt.model, `{"`+tt.module+`":{"get_intl_fw_list":{"fw_list":[{"fwType":2,"fwVer":"1.0.6 Build 200821 Rel.090909","fwUrl":"http://download.tplinkcloud.com/fw.bin","fwTitle":"Hi","fwReleaseDate":"2020-08-21","fwReleaseLog":"fixes"}],"err_code":0}}}`, &sent)
+
+			l, err := d.GetFirmwareListCtx(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(l.List) != 1 || l.List[0].Version != "1.0.6 Build 200821 Rel.090909" {
+				t.Fatalf("got %+v", l.List)
+			}
+			if !strings.HasPrefix(sent[0], `{"`+tt.module+`":`) {
+				t.Fatalf("sent %s", sent[0])
+			}
+		})
+	}
+}
+
+func TestUpdateFirmware(t *testing.T) {
+	var sent []string
+	polls := 0
+	d := &Device{
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				sent = append(sent, cmd)
+				switch {
+				case strings.Contains(cmd, "download_firmware"):
+					return []byte(`{"system":{"download_firmware":{"err_code":0}}}`), nil
+				case strings.Contains(cmd, "get_download_state"):
+					polls++
+					if polls < 2 {
+						return []byte(`{"system":{"get_download_state":{"status":1,"ratio":40,"err_code":0}}}`), nil
+					}
+					return []byte(`{"system":{"get_download_state":{"status":2,"ratio":100,"reboot_time":5,"flash_time":20,"err_code":0}}}`), nil
+				case strings.Contains(cmd, "flash_firmware"):
+					return []byte(`{"system":{"flash_firmware":{"err_code":0}}}`), nil
+				}
+				return []byte(`{}`), nil
+			},
+		},
+	}
+
+	var seen []int
+	err := d.UpdateFirmwareCtx(context.Background(), "http://10.0.0.5:8000/fw.bin", time.Millisecond, func(s *DownloadState) {
+		seen = append(seen, s.Ratio)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(seen) != 2 || seen[1] != 100 {
+		t.Fatalf("progress %v", seen)
+	}
+	if sent[0] != `{"system":{"download_firmware":{"url":"http://10.0.0.5:8000/fw.bin"}}}` {
+		t.Fatalf("sent %s", sent[0])
+	}
+	if !strings.Contains(sent[len(sent)-1], "flash_firmware") {
+		t.Fatalf("last command %s, want flash_firmware", sent[len(sent)-1])
+	}
+
+	for _, bad := range []string{"https://example.com/fw.bin", "fw.bin", "ftp://host/fw.bin"} {
+		if err := d.DownloadFirmwareCtx(context.Background(), bad); err == nil {
+			t.Fatalf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestWaitForDownloadFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		states []string
+		polls  int
+	}{
+		{"error status", []string{`"status":1,"ratio":10`, `"status":-3,"ratio":10`}, 2},
+		{"idle after running", []string{`"status":1,"ratio":10`, `"status":0,"ratio":10`}, 2},
+		{"never started", []string{`"status":0,"ratio":0`}, maxIdlePolls},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polls := 0
+			d := &Device{
+				Transport: TransportFuncs{
+					QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+						s := tt.states[min(polls, len(tt.states)-1)]
+						polls++
+						return []byte(`{"system":{"get_download_state":{` + s + `,"err_code":0}}}`), nil
+					},
+				},
+			}
+
+			_, err := d.WaitForDownloadCtx(context.Background(), time.Millisecond, nil)
+			if !errors.Is(err, ErrDownloadFailed) {
+				t.Fatalf("got %v, want ErrDownloadFailed", err)
+			}
+			if polls != tt.polls {
+				t.Fatalf("polled %d times, want %d", polls, tt.polls)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package kasa
+
+import (
+	"context"
+	"strings"
+)
+
+// mockQuery answers every query with response
+func mockQuery(response string) *Device {
+	return &Device{
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				return []byte(response), nil
+			},
+		},
+	}
+}
+
+// sysinfoDevice answers get_sysinfo with sysinfo, records every other command and answers it with response
+func sysinfoDevice(sysinfo string, response string, sent *[]string) *Device {
+	return &Device{
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				if strings.Contains(cmd, "get_sysinfo") {
+					return []byte(`{"system":{"get_sysinfo":` + sysinfo + `}}`), nil
+				}
+				*sent = append(*sent, cmd)
+				return []byte(response), nil
+			},
+		},
+	}
+}
+
+// ruleDevice answers sysinfo as the given model and records every other command
+func ruleDevice(model string, response string, sent *[]string) *Device {
+	return sysinfoDevice(`{"model":"`+model+`","mic_type":"IOT.SMARTPLUGSWITCH","err_code":0}`, response, sent)
+}
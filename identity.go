@@ -0,0 +1,156 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrDeviceNotFound is returned when a device pinned by DeviceID or MAC can't be found on the local subnets
+var ErrDeviceNotFound = errors.New("kasa: device not found")
+
+// how long a pinned device's identity is trusted before it is checked again
+const identityCheckInterval = 5 * time.Minute
+
+// how long to look for a pinned device which has moved, the search stops as soon as it answers
+const resolveTimeout = 2 * time.Second
+
+// locate is replaced in tests
+var locate = locateDevice
+
+// NewDeviceByID finds the device with the given DeviceID on the local subnets.
+// The device keeps following that DeviceID if its IP address changes.
+func NewDeviceByID(ctx context.Context, id string, opts ...Option) (*Device, error) {
+	d := Device{DeviceID: id}
+	d.apply(opts)
+	if err := d.ResolveCtx(ctx); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// NewDeviceByMAC finds the device with the given MAC address on the local subnets.
+// The device keeps following that MAC if its IP address changes.
+func NewDeviceByMAC(ctx context.Context, mac string, opts ...Option) (*Device, error) {
+	d := Device{MAC: mac}
+	d.apply(opts)
+	if err := d.ResolveCtx(ctx); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// pinned reports if the device should be found by identity rather than its IP
+func (d *Device) pinned() bool {
+	return d.DeviceID != "" || d.MAC != ""
+}
+
+// matches reports if s describes the pinned device
+func (d *Device) matches(s *Sysinfo) bool {
+	if d.DeviceID != "" && d.DeviceID != s.DeviceID {
+		return false
+	}
+	if d.MAC != "" && normalizeMAC(d.MAC) != normalizeMAC(s.MACAddress()) {
+		return false
+	}
+	return true
+}
+
+func normalizeMAC(mac string) string {
+	return strings.ToUpper(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+}
+
+// Resolve looks for the pinned device on the local subnets and updates its IP
+func (d *Device) Resolve() error {
+	return d.ResolveCtx(context.Background())
+}
+
+func (d *Device) ResolveCtx(ctx context.Context) error {
+	if !d.pinned() {
+		return nil
+	}
+
+	rctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	ip, err := locate(rctx, d.matches)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	if d.IP != nil && !d.IP.Equal(ip) {
+		id := d.DeviceID
+		if id == "" {
+			id = d.MAC
+		}
+		d.log().Printf("kasa device %s moved from %s to %s", id, d.IP, ip)
+	}
+	d.IP = ip
+	d.verifiedAt = time.Now()
+	d.mu.Unlock()
+	return nil
+}
+
+// checkIdentity confirms that a pinned device is still at its IP, re-resolving it if not
+func (d *Device) checkIdentity(ctx context.Context) error {
+	if !d.pinned() {
+		return nil
+	}
+
+	d.mu.Lock()
+	known := d.IP != nil
+	fresh := time.Since(d.verifiedAt) < identityCheckInterval
+	d.mu.Unlock()
+
+	if known && fresh {
+		return nil
+	}
+
+	if known {
+		res, err := d.transport().Query(ctx, d.Addr(), CmdGetSysinfo)
+		if err == nil {
+			var kd KasaDevice
+			if err := json.Unmarshal(res, &kd); err == nil && d.matches(&kd.GetSysinfo.Sysinfo) {
+				d.mu.Lock()
+				d.verifiedAt = time.Now()
+				d.mu.Unlock()
+				return nil
+			}
+		}
+	}
+
+	return d.ResolveCtx(ctx)
+}
+
+// relocate is called after a transport failure, it reports if the device was found again and the command is worth retrying
+func (d *Device) relocate(ctx context.Context, err error) bool {
+	if !d.pinned() || errors.Is(err, ErrDevice) || ctx.Err() != nil {
+		return false
+	}
+	return d.ResolveCtx(ctx) == nil
+}
+
+// locateDevice broadcasts a sysinfo query and returns the address of the first device to match
+func locateDevice(ctx context.Context, match func(*Sysinfo) bool) (net.IP, error) {
+	errFound := errors.New("found")
+	var found net.IP
+
+	err := discover(ctx, 2, CmdGetSysinfo, func(addr *net.UDPAddr, kd *KasaDevice) error {
+		if match(&kd.GetSysinfo.Sysinfo) {
+			found = addr.IP.To4()
+			return errFound
+		}
+		return nil
+	})
+	if found != nil {
+		return found, nil
+	}
+	if err != nil && err != errFound {
+		return nil, err
+	}
+	return nil, ErrDeviceNotFound
+}
@@ -0,0 +1,89 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestPinnedDeviceFollowsIdentity(t *testing.T) {
+	oldIP := net.IPv4(192, 168, 1, 20)
+	newIP := net.IPv4(192, 168, 1, 77)
+
+	// a different plug took the old lease, ours moved to a new address
+	sysinfo := map[string]string{
+		"192.168.1.20:9999": `{"system":{"get_sysinfo":{"deviceId":"OTHER","mac":"AA:AA:AA:AA:AA:AA","alias":"wrong","err_code":0}}}`,
+		"192.168.1.77:9999": `{"system":{"get_sysinfo":{"deviceId":"KETTLE","mac":"28:EE:52:AA:9C:31","alias":"kettle","err_code":0}}}`,
+	}
+
+	saved := locate
+	defer func() { locate = saved }()
+	locate = func(ctx context.Context, match func(*Sysinfo) bool) (net.IP, error) {
+		if match(&Sysinfo{DeviceID: "KETTLE", MAC: "28:EE:52:AA:9C:31"}) {
+			return newIP, nil
+		}
+		return nil, ErrDeviceNotFound
+	}
+
+	tests := []struct {
+		name string
+		d    *Device
+	}{
+		{"by id", &Device{DeviceID: "KETTLE"}},
+		{"by mac", &Device{MAC: "28-ee-52-aa-9c-31"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.d
+			d.IP = oldIP
+			d.Port = 9999
+			d.Transport = TransportFuncs{
+				QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+					res, ok := sysinfo[addr]
+					if !ok {
+						return nil, fmt.Errorf("no route to %s", addr)
+					}
+					return []byte(res), nil
+				},
+			}
+
+			s, err := d.GetSettingsCtx(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.Alias != "kettle" {
+				t.Fatalf("talked to %q", s.Alias)
+			}
+			if !d.IP.Equal(newIP) {
+				t.Fatalf("got IP %s, want %s", d.IP, newIP)
+			}
+		})
+	}
+}
+
+func TestPinnedDeviceNotFound(t *testing.T) {
+	saved := locate
+	defer func() { locate = saved }()
+	locate = func(ctx context.Context, match func(*Sysinfo) bool) (net.IP, error) {
+		return nil, ErrDeviceNotFound
+	}
+
+	d := &Device{
+		DeviceID: "GONE",
+		IP:       net.IPv4(192, 168, 1, 20),
+		Port:     9999,
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				return nil, errors.New("connection refused")
+			},
+		},
+	}
+
+	_, err := d.GetSettingsCtx(context.Background())
+	if !errors.Is(err, ErrDeviceNotFound) {
+		t.Fatalf("expected device not found, got %v", err)
+	}
+}
@@ -1,10 +1,14 @@
 package kasa
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"net"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // things to read to learn the protocol:
@@ -22,13 +26,28 @@ type Device struct {
 	IP   net.IP
 	Port int
 
-	OverrideTCP func(ctx context.Context, cmd string) ([]byte, error)
-	OverrideUDP func(ctx context.Context, cmd string) error
+	// Transport carries the commands, DefaultTransport is used if nil.
+	// Wrap it with Chain to add tracing, metrics, retries or rate limiting.
+	Transport Transport
+
+	// DeviceID and MAC pin the device's identity, if either is set the device is found again
+	// with a broadcast when its IP stops answering or a different device answers there
+	DeviceID string
+	MAC      string
+
+	mu         sync.Mutex // guards IP, verifiedAt and caps
+	verifiedAt time.Time
+	logger     kasalogger
+	caps       Capabilities
+	capsKnown  bool
 }
 
 // NewDevice sets up a new Kasa device for polling
-func NewDevice(ip string) (*Device, error) {
-	d := Device{Port: 9999}
+//
+//	d, err := kasa.NewDevice("192.168.1.20", kasa.WithReadTimeout(5*time.Second), kasa.WithRetry(3, 100*time.Millisecond))
+func NewDevice(ip string, opts ...Option) (*Device, error) {
+	d := Device{}
+	d.apply(opts)
 
 	d.IP = net.ParseIP(ip)
 
@@ -58,15 +77,17 @@ func NewDevice(ip string) (*Device, error) {
 	return &d, nil
 }
 
-func NewDeviceIP(ip net.IP) (*Device, error) {
+func NewDeviceIP(ip net.IP, opts ...Option) (*Device, error) {
 	d := Device{
-		IP:   ip,
-		Port: 9999,
+		IP: ip,
 	}
+	d.apply(opts)
 	return &d, nil
 }
 
 func (d *Device) Addr() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	return net.JoinHostPort(d.IP.String(), strconv.Itoa(d.Port))
 }
 
@@ -75,9 +96,10 @@ type KasaErr struct {
 	ErrMsg  string `json:"err_msg"`
 }
 
+// OK returns a *ResponseError if the device reported a failure, match it with errors.Is
 func (e KasaErr) OK() error {
 	if e.ErrCode != 0 {
-		return fmt.Errorf("kasa error %d: %s", e.ErrCode, e.ErrMsg)
+		return &ResponseError{KasaErr: e}
 	}
 	return nil
 }
@@ -90,6 +112,8 @@ type KasaDevice struct {
 	Countdown   Countdown   `json:"count_down"`
 	Emeter      EmeterSub   `json:"emeter"`
 	LightSensor LightSensor `json:"smartlife.iot.LAS"`
+	PIR         PIRSensor   `json:"smartlife.iot.PIR"`
+	Lighting    Lighting    `json:"smartlife.iot.smartbulb.lightingservice"`
 }
 
 // GetSysinfo is defined by kasa devices
@@ -114,6 +138,7 @@ type Sysinfo struct {
 	MIC        string `json:"mic_type"`
 	Feature    string `json:"feature"` // "TIM" "TIM:ENE"
 	MAC        string `json:"mac"`
+	MICMAC     string `json:"mic_mac"` // bulbs, no separators
 	Updating   uint   `json:"updating"`
 	LEDOff     uint   `json:"led_off"`
 	RelayState uint   `json:"relay_state"`
@@ -123,13 +148,55 @@ type Sysinfo struct {
 	ActiveMode string `json:"active_mode"`
 	DevName    string `json:"dev_name"`
 	// NextAction     ...      `json:"next_action"`
-	Children       []Child  `json:"children"`
-	NumChildren    uint     `json:"child_num"`
-	NTCState       int      `json:"ntc_state"`
-	PreferredState []Preset `json:"preferred_state"`
+	Children       []Child      `json:"children"`
+	NumChildren    uint         `json:"child_num"`
+	NTCState       int          `json:"ntc_state"`
+	PreferredState []Preset     `json:"preferred_state"`
+	Length         uint         `json:"length"` // light strip zones
+	EffectState    *EffectState `json:"lighting_effect_state,omitempty"`
+	// bulbs report these instead of relay_state and brightness
+	LightState          *LightState `json:"light_state,omitempty"`
+	IsDimmable          uint        `json:"is_dimmable"`
+	IsColor             uint        `json:"is_color"`
+	IsVariableColorTemp uint        `json:"is_variable_color_temp"`
 	KasaErr
 }
 
+// IsOn reports if the relay is closed or the bulb is lit
+func (s *Sysinfo) IsOn() bool {
+	if s.LightState != nil {
+		return s.LightState.OnOff == 1
+	}
+	return s.RelayState == 1
+}
+
+// Level is the brightness, 1-100. Plugs and switches without a dimmer report 100,
+// bulbs that are off report the level they will come back on at.
+func (s *Sysinfo) Level() uint {
+	if l := s.LightState; l != nil {
+		if l.OnOff == 0 && l.DefaultOn != nil {
+			return l.DefaultOn.Brightness
+		}
+		return uint(l.Brightness)
+	}
+	if s.Brightness > 0 {
+		return s.Brightness
+	}
+	return 100
+}
+
+// MACAddress is the device's MAC address, from mac or, on bulbs, mic_mac
+func (s *Sysinfo) MACAddress() string {
+	if s.MAC != "" || len(s.MICMAC) != 12 {
+		return s.MAC
+	}
+	pairs := make([]string, 0, 6)
+	for i := 0; i < 12; i += 2 {
+		pairs = append(pairs, s.MICMAC[i:i+2])
+	}
+	return strings.ToUpper(strings.Join(pairs, ":"))
+}
+
 // "next_action":{"type":-1}
 
 // Dimmer is defined by kasa devices
@@ -176,15 +243,16 @@ type Preset struct {
 type NetIf struct {
 	StaInfo    StaInfo    `json:"get_stainfo"`
 	SetStaInfo SetStaInfo `json:"set_stainfo"`
+	ScanInfo   ScanInfo   `json:"get_scaninfo"`
 
 	KasaErr
 }
 
 // StaInfo is defined by kasa devices
 type StaInfo struct {
-	SSID    string `json:"ssid"`
-	KeyType int    `json:"key_type"`
-	RSSI    int    `json:"rssi"`
+	SSID    string  `json:"ssid"`
+	KeyType KeyType `json:"key_type"`
+	RSSI    int     `json:"rssi"`
 	KasaErr
 }
 
@@ -194,15 +262,19 @@ type SetStaInfo struct {
 
 // {"emeter":{"get_realtime":{"current_ma":1799,"voltage_mv":121882,"power_mw":174545,"total_wh":547,"err_code":0}}}
 // {"emeter":{"get_daystat":{"day_list":[{"year":2021,"month":2,"day":6,"energy_wh":842},{"year":2021,"month":2,"day":7,"energy_wh":1142}],"err_code":0}}}
+// {"emeter":{"get_monthstat":{"month_list":[{"year":2021,"month":1,"energy_wh":25321},{"year":2021,"month":2,"energy_wh":21870}],"err_code":0}}}
 
 // EmeterSub is defined by kasa devices
 type EmeterSub struct {
-	Realtime EmeterRealtime `json:"get_realtime"`
-	DayStat  EmeterDaystat  `json:"get_daystat"`
+	Realtime  EmeterRealtime  `json:"get_realtime"`
+	DayStat   EmeterDaystat   `json:"get_daystat"`
+	MonthStat EmeterMonthstat `json:"get_monthstat"`
 	KasaErr
 }
 
-// EmeterRealtime is defined by kasa devices
+// EmeterRealtime is defined by kasa devices.
+// Older firmware (HS110 v1) reports voltage, current, power and total as floats in V, A, W and kWh;
+// those are converted so the milli-unit fields and the accessors are always filled in.
 type EmeterRealtime struct {
 	Slot      uint `json:"slot_id"`
 	CurrentMA uint `json:"current_ma"`
@@ -212,6 +284,71 @@ type EmeterRealtime struct {
 	KasaErr
 }
 
+// {"emeter":{"get_realtime":{"current":0.012227,"voltage":240.074398,"power":0,"total":0.001,"err_code":0}}}
+
+func (e *EmeterRealtime) UnmarshalJSON(b []byte) error {
+	type plain EmeterRealtime
+	var raw struct {
+		plain
+		Voltage *float64 `json:"voltage"`
+		Current *float64 `json:"current"`
+		Power   *float64 `json:"power"`
+		Total   *float64 `json:"total"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*e = EmeterRealtime(raw.plain)
+	fromFloat(&e.VoltageMV, raw.Voltage)
+	fromFloat(&e.CurrentMA, raw.Current)
+	fromFloat(&e.PowerMW, raw.Power)
+	fromFloat(&e.TotalWH, raw.Total) // kWh to Wh
+	return nil
+}
+
+// fromFloat fills a milli-unit field from the old float format if the new field was missing
+func fromFloat(milli *uint, f *float64) {
+	if *milli == 0 && f != nil && *f > 0 {
+		*milli = uint(math.Round(*f * 1000))
+	}
+}
+
+// Volts returns the voltage in V
+func (e *EmeterRealtime) Volts() float64 {
+	return float64(e.VoltageMV) / 1000
+}
+
+// Amps returns the current in A
+func (e *EmeterRealtime) Amps() float64 {
+	return float64(e.CurrentMA) / 1000
+}
+
+// Watts returns the power in W
+func (e *EmeterRealtime) Watts() float64 {
+	return float64(e.PowerMW) / 1000
+}
+
+// KWh returns the energy used since the last reset in kWh
+func (e *EmeterRealtime) KWh() float64 {
+	return float64(e.TotalWH) / 1000
+}
+
+// EmeterGain is defined by kasa devices
+type EmeterGain struct {
+	VGain int `json:"vgain"`
+	IGain int `json:"igain"`
+	KasaErr
+}
+
+// CalibratedGain scales a gain so that the device's reading matches a reference meter's
+func CalibratedGain(gain int, reading, reference float64) int {
+	if reading <= 0 || reference <= 0 {
+		return gain
+	}
+	return int(math.Round(float64(gain) * reference / reading))
+}
+
 // EmeterDaystat is defined by kasa devices
 type EmeterDaystat struct {
 	List []EmeterDay `json:"day_list"`
@@ -226,6 +363,28 @@ type EmeterDay struct {
 	WH    uint `json:"energy_wh"`
 }
 
+// EmeterMonthstat is defined by kasa devices
+type EmeterMonthstat struct {
+	List []EmeterMonth `json:"month_list"`
+	KasaErr
+}
+
+// EmeterMonth is defined by kasa devices
+type EmeterMonth struct {
+	Year  uint `json:"year"`
+	Month uint `json:"month"`
+	WH    uint `json:"energy_wh"`
+}
+
+// Total returns the sum of every month in the list
+func (m *EmeterMonthstat) Total() uint {
+	var t uint
+	for _, v := range m.List {
+		t += v.WH
+	}
+	return t
+}
+
 // Countdown is defined by kasa devices
 type Countdown struct {
 	GetRules GetRules `json:"get_rules"`
@@ -275,7 +434,7 @@ type LightSensorConfig struct {
 }
 
 type LightSensorDev struct {
-	ID        string             `json:"hw_id"`
+	ID        int                `json:"hw_id"`
 	Enable    uint               `json:"enable"`
 	DarkIndex uint               `json:"dark_index"`
 	MinADC    uint               `json:"min_adc"`
@@ -283,6 +442,16 @@ type LightSensorDev struct {
 	Levels    []LightSensorLevel `json:"level_array"`
 }
 
+// LevelIndex returns the index of the named level
+func (l *LightSensorDev) LevelIndex(name string) (int, bool) {
+	for i, v := range l.Levels {
+		if strings.EqualFold(v.Name, name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 type LightSensorLevel struct {
 	Name  string `json:"name"`
 	ADC   uint   `json:"adc"`
@@ -310,3 +479,22 @@ type PIRSensorConfig struct {
 }
 
 // { "smartlife.iot.PIR": { "get_config": { "enable": 1, "version": "1.0", "trigger_index": 1, "cold_time": 60000, "min_adc": 0, "max_adc": 4095, "array": [80, 50, 20, 0], "err_code": 0 } } }
+
+// Lighting is the bulb lighting service
+type Lighting struct {
+	LightState LightState `json:"get_light_state"`
+}
+
+// LightState is a bulb's current light; when the bulb is off the settings it will come back on with are in DefaultOn
+type LightState struct {
+	OnOff      int     `json:"on_off"`
+	Mode       string  `json:"mode"`
+	Hue        int     `json:"hue"`
+	Saturation int     `json:"saturation"`
+	ColorTemp  int     `json:"color_temp"`
+	Brightness int     `json:"brightness"`
+	DefaultOn  *Preset `json:"dft_on_state,omitempty"`
+	KasaErr
+}
+
+// {"smartlife.iot.smartbulb.lightingservice":{"get_light_state":{"on_off":1,"mode":"normal","hue":0,"saturation":0,"color_temp":2700,"brightness":50,"err_code":0}}}
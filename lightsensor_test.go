@@ -0,0 +1,54 @@
+package kasa
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLightSensorSetters(t *testing.T) {
+	config := `{"smartlife.iot.LAS":{"get_config":{"devs":[{"hw_id":0,"enable":1,"dark_index":0,"min_adc":0,"max_adc":2450,"level_array":[{"name":"cloudy","adc":490,"value":20},{"name":"overcast","adc":294,"value":12},{"name":"dawn","adc":222,"value":9}]}],"ver":"1.0","err_code":0}}}`
+
+	tests := []struct {
+		name      string
+		call      func(d *Device) error
+		want      string
+		shouldErr bool
+	}{
+		{"enable", func(d *Device) error { return d.SetLightSensorEnableCtx(context.Background(), false) }, `{"smartlife.iot.LAS":{"set_enable":{"enable":0}}}`, false},
+		{"dark index", func(d *Device) error { return d.SetLightSensorDarkIndexCtx(context.Background(), 2) }, `{"smartlife.iot.LAS":{"set_dark_index":{"dark_index":2}}}`, false},
+		{"dark level", func(d *Device) error { return d.SetLightSensorDarkLevelCtx(context.Background(), "Overcast") }, `{"smartlife.iot.LAS":{"set_dark_index":{"dark_index":1}}}`, false},
+		{"level", func(d *Device) error { return d.SetLightSensorLevelCtx(context.Background(), "dawn", 5) }, `{"smartlife.iot.LAS":{"set_brt_level":{"index":2,"value":5}}}`, false},
+		{"unknown level", func(d *Device) error { return d.SetLightSensorLevelCtx(context.Background(), "eclipse", 5) }, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent string
+			d := &Device{
+				Transport: TransportFuncs{
+					QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+						return []byte(config), nil
+					},
+					SendFunc: func(ctx context.Context, addr string, cmd string) error {
+						sent = cmd
+						return nil
+					},
+				},
+			}
+
+			err := tt.call(d)
+			if tt.shouldErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sent != tt.want {
+				t.Fatalf("sent %s, want %s", sent, tt.want)
+			}
+		})
+	}
+}
@@ -41,3 +41,11 @@ type kasalogger interface {
 func SetLogger(l kasalogger) {
 	klogger = l
 }
+
+// log returns the device's own logger if it was given one by WithLogger
+func (d *Device) log() kasalogger {
+	if d.logger != nil {
+		return d.logger
+	}
+	return klogger
+}
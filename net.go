@@ -3,45 +3,116 @@ package kasa
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"syscall"
 	"time"
 )
 
-func (d *Device) sendTCP(ctx context.Context, cmd string) ([]byte, error) {
-	if d.OverrideTCP != nil {
-		return d.OverrideTCP(ctx, cmd)
+// NetTransport is the default Transport, it talks directly to the device, one connection per command
+type NetTransport struct {
+	// DialTimeout limits connecting, if zero the context's deadline is used or one second if it has none
+	DialTimeout time.Duration
+
+	// ReadTimeout limits each exchange, the context's deadline applies if it is sooner
+	ReadTimeout time.Duration
+
+	// Logger replaces the package logger for this transport
+	Logger kasalogger
+
+	// Acknowledged makes Send wait for the device's UDP reply and check it for errors.
+	// The default is fire-and-forget, which is faster but never reports device-side failures or lost packets.
+	Acknowledged bool
+
+	// AckTimeout is how long an acknowledged Send waits for each reply, defaults to 1 second
+	AckTimeout time.Duration
+
+	// Retries is how many times an acknowledged Send is repeated after a reply times out
+	Retries int
+}
+
+// Query sends the command over TCP and returns the unscrambled response
+func (t *NetTransport) Query(ctx context.Context, addr string, cmd string) ([]byte, error) {
+	conn, err := t.dialTCP(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return t.exchangeTCP(ctx, conn, cmd)
+}
+
+func (t *NetTransport) log() kasalogger {
+	if t.Logger != nil {
+		return t.Logger
 	}
+	return klogger
+}
 
-	// is this needed if we do it on the connection based on ctx?
-	dialer := &net.Dialer{
-		Timeout:  1 * time.Second,
-		Deadline: time.Now().Add(2 * time.Second),
+func (t *NetTransport) dialTCP(ctx context.Context, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: t.DialTimeout}
+	if _, ok := ctx.Deadline(); !ok && t.DialTimeout == 0 {
+		dialer.Timeout = 1 * time.Second
 	}
 
-	conn, err := dialer.DialContext(ctx, "tcp4", d.Addr())
+	conn, err := dialer.DialContext(ctx, "tcp4", addr)
 	if err != nil {
-		klogger.Printf("cannot connnect to device: %s", err.Error())
+		t.log().Printf("cannot connnect to device: %s", err.Error())
 		return nil, err
 	}
-	defer conn.Close()
+	return conn, nil
+}
+
+// unsentError marks an exchange that failed before the device could have acted on the command, so it is safe to repeat
+type unsentError struct {
+	error
+}
+
+func (e unsentError) Unwrap() error {
+	return e.error
+}
+
+// hungUp reports whether err is the peer closing or resetting the connection, as opposed to a timeout
+func hungUp(err error) bool {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return false
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
 
-	if d, ok := ctx.Deadline(); ok {
-		_ = conn.SetDeadline(d)
+// exchangeTCP writes one length-prefixed command and reads one length-prefixed response
+func (t *NetTransport) exchangeTCP(ctx context.Context, conn net.Conn, cmd string) ([]byte, error) {
+	// always set, this clears any deadline left over from a previous exchange on a reused connection
+	deadline, _ := ctx.Deadline()
+	if t.ReadTimeout > 0 {
+		if rd := time.Now().Add(t.ReadTimeout); deadline.IsZero() || rd.Before(deadline) {
+			deadline = rd
+		}
 	}
+	_ = conn.SetDeadline(deadline)
 
 	// send the command with the uint32 "header"
 	payload := ScrambleTCP(cmd)
-	if _, err = conn.Write(payload); err != nil {
-		klogger.Printf("cannot send command to device: %s", err.Error())
+	if _, err := conn.Write(payload); err != nil {
+		t.log().Printf("cannot send command to device: %s", err.Error())
+		if hungUp(err) {
+			return nil, unsentError{err}
+		}
 		return nil, err
 	}
 
 	// read the uint32 "header" to get the size of the rest of the block
 	header := make([]byte, 4)
-	if _, err := io.ReadFull(conn, header); err != nil {
-		return nil, fmt.Errorf("failed to read header: %w", err)
+	if n, err := io.ReadFull(conn, header); err != nil {
+		err = fmt.Errorf("failed to read header: %w", err)
+		if n == 0 && hungUp(err) {
+			// a stale connection, the device dropped it before reading the command
+			return nil, unsentError{err}
+		}
+		return nil, err
 	}
 	size := binary.BigEndian.Uint32(header)
 
@@ -53,20 +124,84 @@ func (d *Device) sendTCP(ctx context.Context, cmd string) ([]byte, error) {
 	return Unscramble(data), nil
 }
 
-func (d *Device) sendUDP(ctx context.Context, cmd string) error {
-	if d.OverrideUDP != nil {
-		return d.OverrideUDP(ctx, cmd)
-	}
-
-	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: d.IP, Port: d.Port})
+// Send writes the command to the device over UDP.
+// Unless Acknowledged is set it does not wait for a response.
+func (t *NetTransport) Send(ctx context.Context, addr string, cmd string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp4", addr)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
 	payload := Scramble(cmd)
-	if _, err = conn.Write(payload); err != nil {
+
+	if !t.Acknowledged {
+		if _, err = conn.Write(payload); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	wait := t.AckTimeout
+	if wait <= 0 {
+		wait = 1 * time.Second
+	}
+
+	buffer := make([]byte, bufsize)
+	for attempt := 0; ; attempt++ {
+		if _, err = conn.Write(payload); err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(wait)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		_ = conn.SetReadDeadline(deadline)
+
+		n, err := conn.Read(buffer)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() && attempt < t.Retries && ctx.Err() == nil {
+				continue
+			}
+			return err
+		}
+
+		return checkResponse(Unscramble(buffer[:n]))
+	}
+}
+
+func (d *Device) transport() Transport {
+	if d.Transport != nil {
+		return d.Transport
+	}
+	return DefaultTransport
+}
+
+func (d *Device) sendTCP(ctx context.Context, cmd string) ([]byte, error) {
+	if err := d.checkIdentity(ctx); err != nil {
+		return nil, err
+	}
+
+	res, err := d.transport().Query(ctx, d.Addr(), cmd)
+	if err != nil && d.relocate(ctx, err) {
+		res, err = d.transport().Query(ctx, d.Addr(), cmd)
+	}
+	if err != nil {
+		return nil, transportErr("query", d.Addr(), err)
+	}
+	return res, nil
+}
+
+func (d *Device) sendUDP(ctx context.Context, cmd string) error {
+	if err := d.checkIdentity(ctx); err != nil {
 		return err
 	}
-	return nil
+
+	err := d.transport().Send(ctx, d.Addr(), cmd)
+	if err != nil && d.relocate(ctx, err) {
+		err = d.transport().Send(ctx, d.Addr(), cmd)
+	}
+	return transportErr("send", d.Addr(), err)
 }
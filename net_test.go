@@ -0,0 +1,75 @@
+package kasa
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeUDPDevice replies to every packet with response, ignoring the first drop packets
+func fakeUDPDevice(t *testing.T, response string, drop int32) (string, *int32) {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var received int32
+	go func() {
+		buffer := make([]byte, bufsize)
+		for {
+			_, addr, err := conn.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			if atomic.AddInt32(&received, 1) <= drop {
+				continue
+			}
+			_, _ = conn.WriteToUDP(Scramble(response), addr)
+		}
+	}()
+
+	return conn.LocalAddr().String(), &received
+}
+
+func TestAcknowledgedSend(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		drop      int32
+		retries   int
+		wantSent  int32
+		shouldErr bool
+	}{
+		{"ok", `{"system":{"set_relay_state":{"err_code":0}}}`, 0, 0, 1, false},
+		{"device error", `{"system":{"set_relay_state":{"err_code":-3,"err_msg":"invalid argument"}}}`, 0, 0, 1, true},
+		{"module not supported", `{"smartlife.iot.dimmer":{"err_code":-1,"err_msg":"module not support"}}`, 0, 0, 1, true},
+		{"retry after loss", `{"system":{"set_relay_state":{"err_code":0}}}`, 1, 2, 2, false},
+		{"lost", `{"system":{"set_relay_state":{"err_code":0}}}`, 5, 1, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, received := fakeUDPDevice(t, tt.response, tt.drop)
+
+			tr := &NetTransport{Acknowledged: true, AckTimeout: 50 * time.Millisecond, Retries: tt.retries}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			err := tr.Send(ctx, addr, `{"system":{"set_relay_state":{"state":1}}}`)
+			if tt.shouldErr && err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !tt.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n := atomic.LoadInt32(received); n != tt.wantSent {
+				t.Fatalf("device got %d packets, want %d", n, tt.wantSent)
+			}
+		})
+	}
+}
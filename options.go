@@ -0,0 +1,112 @@
+package kasa
+
+import (
+	"time"
+)
+
+// Option configures a Device when it is created by NewDevice or NewDeviceIP
+type Option func(*deviceOptions)
+
+type deviceOptions struct {
+	port        int
+	dialTimeout time.Duration
+	readTimeout time.Duration
+	ack         bool
+	ackRetries  int
+	attempts    int
+	backoff     time.Duration
+	logger      kasalogger
+	transport   Transport
+	middleware  []Middleware
+}
+
+// WithPort sets an alternate port, useful with port-forwarding (default 9999)
+func WithPort(port int) Option {
+	return func(o *deviceOptions) {
+		o.port = port
+	}
+}
+
+// WithDialTimeout limits how long connecting may take.
+// If not set, the context's deadline is used, or one second if the context has none.
+func WithDialTimeout(d time.Duration) Option {
+	return func(o *deviceOptions) {
+		o.dialTimeout = d
+	}
+}
+
+// WithReadTimeout limits how long each command may wait for the device's response,
+// the context's deadline still applies if it is sooner
+func WithReadTimeout(d time.Duration) Option {
+	return func(o *deviceOptions) {
+		o.readTimeout = d
+	}
+}
+
+// WithAcknowledgedUDP makes setters wait for the device's reply, see NetTransport.Acknowledged
+func WithAcknowledgedUDP(retries int) Option {
+	return func(o *deviceOptions) {
+		o.ack = true
+		o.ackRetries = retries
+	}
+}
+
+// WithRetry retries commands which fail to reach the device, see RetryMiddleware
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(o *deviceOptions) {
+		o.attempts = attempts
+		o.backoff = backoff
+	}
+}
+
+// WithLogger sets a logger for this device instead of the package logger set by SetLogger
+func WithLogger(l kasalogger) Option {
+	return func(o *deviceOptions) {
+		o.logger = l
+	}
+}
+
+// WithTransport replaces the default network transport.
+// The timeout, acknowledgement and logger options only configure the default transport.
+func WithTransport(t Transport) Option {
+	return func(o *deviceOptions) {
+		o.transport = t
+	}
+}
+
+// WithMiddleware wraps the device's transport, the first middleware listed is the outermost
+func WithMiddleware(mw ...Middleware) Option {
+	return func(o *deviceOptions) {
+		o.middleware = append(o.middleware, mw...)
+	}
+}
+
+func (d *Device) apply(opts []Option) {
+	o := deviceOptions{port: 9999}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	d.Port = o.port
+	d.logger = o.logger
+
+	t := o.transport
+	if t == nil && o.dialTimeout == 0 && o.readTimeout == 0 && !o.ack && o.logger == nil {
+		t = DefaultTransport
+	}
+	if t == nil {
+		t = &NetTransport{
+			DialTimeout:  o.dialTimeout,
+			ReadTimeout:  o.readTimeout,
+			Acknowledged: o.ack,
+			Retries:      o.ackRetries,
+			Logger:       o.logger,
+		}
+	}
+
+	mw := o.middleware
+	if o.attempts > 1 {
+		mw = append(mw, RetryMiddleware(o.attempts, o.backoff))
+	}
+	d.Transport = Chain(t, mw...)
+}
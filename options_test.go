@@ -0,0 +1,79 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDeviceOptions(t *testing.T) {
+	var seen []string
+	mw := func(next Transport) Transport {
+		return TransportFuncs{
+			SendFunc: func(ctx context.Context, addr string, cmd string) error {
+				seen = append(seen, addr)
+				return next.Send(ctx, addr, cmd)
+			},
+		}
+	}
+	base := TransportFuncs{
+		SendFunc: func(ctx context.Context, addr string, cmd string) error {
+			return nil
+		},
+	}
+
+	d, err := NewDeviceIP(net.IPv4(192, 168, 1, 20), WithPort(10000), WithTransport(base), WithMiddleware(mw))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Port != 10000 {
+		t.Fatalf("got port %d, want 10000", d.Port)
+	}
+
+	if err := d.SetRelayStateCtx(context.Background(), true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(seen) != 1 || seen[0] != "192.168.1.20:10000" {
+		t.Fatalf("middleware saw %v", seen)
+	}
+
+	d, _ = NewDeviceIP(net.IPv4(192, 168, 1, 20))
+	if d.Port != 9999 || d.Transport != DefaultTransport {
+		t.Fatalf("expected defaults, got port %d transport %T", d.Port, d.Transport)
+	}
+}
+
+func TestReadTimeoutOption(t *testing.T) {
+	// accepts connections but never answers
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			// hold it open until the client gives up
+			_, _ = io.Copy(io.Discard, conn)
+			conn.Close()
+		}
+	}()
+
+	port := l.Addr().(*net.TCPAddr).Port
+	d, _ := NewDeviceIP(net.IPv4(127, 0, 0, 1), WithPort(port), WithReadTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	_, err = d.GetSettingsCtx(context.Background())
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("read timeout not applied, took %s", elapsed)
+	}
+}
@@ -0,0 +1,107 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PIRRange is the motion sensor trigger distance, the presets index PIRSensorConfig.Data
+type PIRRange uint
+
+const (
+	PIRFar    PIRRange = 0
+	PIRMid    PIRRange = 1
+	PIRNear   PIRRange = 2
+	PIRCustom PIRRange = 3
+)
+
+var pirRangeNames = [...]string{"far", "mid", "near", "custom"}
+
+func (r PIRRange) String() string {
+	if int(r) < len(pirRangeNames) {
+		return pirRangeNames[r]
+	}
+	return fmt.Sprintf("PIRRange(%d)", uint(r))
+}
+
+// ParsePIRRange accepts "far", "mid" or "near"
+func ParsePIRRange(s string) (PIRRange, error) {
+	for i, n := range pirRangeNames[:PIRCustom] {
+		if strings.EqualFold(s, n) {
+			return PIRRange(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown range %q (far|mid|near)", s)
+}
+
+// Range returns the selected trigger range
+func (c *PIRSensorConfig) Range() PIRRange {
+	return PIRRange(c.TriggerIndex)
+}
+
+// RangeValue returns the sensitivity value of the selected range, roughly in decimeters
+func (c *PIRSensorConfig) RangeValue() uint {
+	if int(c.TriggerIndex) < len(c.Data) {
+		return c.Data[c.TriggerIndex]
+	}
+	return 0
+}
+
+// Cold returns how long the light stays on after the last motion
+func (c *PIRSensorConfig) Cold() time.Duration {
+	return time.Duration(c.ColdTime) * time.Millisecond
+}
+
+// GetPIRConfig returns the motion sensor configuration from KS200M/ES20M switches
+func (d *Device) GetPIRConfig() (*PIRSensorConfig, error) {
+	return d.GetPIRConfigCtx(context.Background())
+}
+
+func (d *Device) GetPIRConfigCtx(ctx context.Context) (*PIRSensorConfig, error) {
+	var c PIRSensorConfig
+	if err := d.call(ctx, NewRequest().Add("smartlife.iot.PIR", "get_config", nil), &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// SetPIREnable turns the motion sensor on or off
+func (d *Device) SetPIREnable(enable bool) error {
+	return d.SetPIREnableCtx(context.Background(), enable)
+}
+
+func (d *Device) SetPIREnableCtx(ctx context.Context, enable bool) error {
+	return d.send(ctx, NewRequest().Add("smartlife.iot.PIR", "set_enable", Params{"enable": boolToInt(enable)}))
+}
+
+// SetPIRColdTime sets how long the light stays on after the last motion
+func (d *Device) SetPIRColdTime(cold time.Duration) error {
+	return d.SetPIRColdTimeCtx(context.Background(), cold)
+}
+
+func (d *Device) SetPIRColdTimeCtx(ctx context.Context, cold time.Duration) error {
+	return d.send(ctx, NewRequest().Add("smartlife.iot.PIR", "set_cold_time", Params{"cold_time": cold.Milliseconds()}))
+}
+
+// SetPIRRange selects one of the far, mid or near trigger presets
+func (d *Device) SetPIRRange(r PIRRange) error {
+	return d.SetPIRRangeCtx(context.Background(), r)
+}
+
+func (d *Device) SetPIRRangeCtx(ctx context.Context, r PIRRange) error {
+	if r >= PIRCustom {
+		return fmt.Errorf("use SetPIRCustomRange for custom ranges")
+	}
+	return d.send(ctx, NewRequest().Add("smartlife.iot.PIR", "set_trigger_sens", Params{"index": r}))
+}
+
+// SetPIRCustomRange selects the custom trigger range with the given value, roughly in decimeters
+func (d *Device) SetPIRCustomRange(value uint) error {
+	return d.SetPIRCustomRangeCtx(context.Background(), value)
+}
+
+func (d *Device) SetPIRCustomRangeCtx(ctx context.Context, value uint) error {
+	return d.send(ctx, NewRequest().Add("smartlife.iot.PIR", "set_trigger_sens", Params{"index": PIRCustom, "value": value}))
+}
@@ -0,0 +1,56 @@
+package kasa
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPIRConfig(t *testing.T) {
+	d := mockQuery(`{"smartlife.iot.PIR":{"get_config":{"enable":1,"version":"1.0","trigger_index":1,"cold_time":60000,"min_adc":0,"max_adc":4095,"array":[80,50,20,0],"err_code":0}}}`)
+
+	c, err := d.GetPIRConfigCtx(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Range() != PIRMid || c.RangeValue() != 50 || c.Cold() != time.Minute {
+		t.Fatalf("got range %s value %d cold %s", c.Range(), c.RangeValue(), c.Cold())
+	}
+}
+
+func TestPIRSetters(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(d *Device) error
+		want string
+	}{
+		{"enable", func(d *Device) error { return d.SetPIREnableCtx(context.Background(), true) }, `{"smartlife.iot.PIR":{"set_enable":{"enable":1}}}`},
+		{"cold time", func(d *Device) error { return d.SetPIRColdTimeCtx(context.Background(), 2*time.Minute) }, `{"smartlife.iot.PIR":{"set_cold_time":{"cold_time":120000}}}`},
+		{"near", func(d *Device) error { return d.SetPIRRangeCtx(context.Background(), PIRNear) }, `{"smartlife.iot.PIR":{"set_trigger_sens":{"index":2}}}`},
+		{"custom", func(d *Device) error { return d.SetPIRCustomRangeCtx(context.Background(), 35) }, `{"smartlife.iot.PIR":{"set_trigger_sens":{"index":3,"value":35}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent string
+			d := &Device{
+				Transport: TransportFuncs{
+					SendFunc: func(ctx context.Context, addr string, cmd string) error {
+						sent = cmd
+						return nil
+					},
+				},
+			}
+			if err := tt.call(d); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sent != tt.want {
+				t.Fatalf("sent %s, want %s", sent, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParsePIRRange("sideways"); err == nil {
+		t.Fatal("expected an error for an unknown range")
+	}
+}
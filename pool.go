@@ -0,0 +1,121 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// PooledTransport keeps a TCP connection open to each device and reuses it for every Query.
+// This saves the handshake when polling many devices at a high rate.
+// If the device has dropped the connection before the command reached it, the command is retried on a new one.
+// Timeouts are never retried since the device may already have acted on the command.
+// Send still uses UDP. A single PooledTransport can be shared by many devices.
+type PooledTransport struct {
+	NetTransport
+
+	// IdleTimeout closes connections which have not been used for this long, 0 never closes them.
+	// Kasa devices drop idle clients on their own, so keeping this short avoids a wasted write.
+	IdleTimeout time.Duration
+
+	mu    sync.Mutex
+	conns map[string]*pooledConn
+}
+
+type pooledConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+	used time.Time
+}
+
+// NewPooledTransport returns a keep-alive transport, idle is passed to IdleTimeout
+func NewPooledTransport(idle time.Duration) *PooledTransport {
+	return &PooledTransport{
+		IdleTimeout: idle,
+		conns:       make(map[string]*pooledConn),
+	}
+}
+
+func (p *PooledTransport) get(addr string) *pooledConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conns == nil {
+		p.conns = make(map[string]*pooledConn)
+	}
+
+	pc, ok := p.conns[addr]
+	if !ok {
+		pc = &pooledConn{}
+		p.conns[addr] = pc
+	}
+	return pc
+}
+
+// Query sends the command over the device's open connection, dialing one if needed.
+// Commands to the same device are serialized since the protocol has no request IDs.
+func (p *PooledTransport) Query(ctx context.Context, addr string, cmd string) ([]byte, error) {
+	pc := p.get(addr)
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.conn != nil && p.IdleTimeout > 0 && time.Since(pc.used) > p.IdleTimeout {
+		pc.close()
+	}
+
+	reused := pc.conn != nil
+	if !reused {
+		conn, err := p.dialTCP(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		pc.conn = conn
+	}
+
+	res, err := p.exchangeTCP(ctx, pc.conn, cmd)
+	if err != nil {
+		pc.close()
+		var unsent unsentError
+		if !reused || ctx.Err() != nil || !errors.As(err, &unsent) {
+			return nil, err
+		}
+
+		// the device closed the old connection, try once more on a new one
+		conn, derr := p.dialTCP(ctx, addr)
+		if derr != nil {
+			return nil, derr
+		}
+		pc.conn = conn
+		if res, err = p.exchangeTCP(ctx, pc.conn, cmd); err != nil {
+			pc.close()
+			return nil, err
+		}
+	}
+
+	pc.used = time.Now()
+	return res, nil
+}
+
+func (pc *pooledConn) close() {
+	if pc.conn != nil {
+		_ = pc.conn.Close()
+		pc.conn = nil
+	}
+}
+
+// Close closes every open connection, the transport remains usable and will redial as needed
+func (p *PooledTransport) Close() error {
+	p.mu.Lock()
+	conns := p.conns
+	p.conns = make(map[string]*pooledConn)
+	p.mu.Unlock()
+
+	for _, pc := range conns {
+		pc.mu.Lock()
+		pc.close()
+		pc.mu.Unlock()
+	}
+	return nil
+}
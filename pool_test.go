@@ -0,0 +1,151 @@
+package kasa
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeTCPDevice answers every command with the same response, closing the connection after perConn replies (0 = never)
+func fakeTCPDevice(t *testing.T, response string, perConn int) (string, *int32) {
+	t.Helper()
+
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var accepts int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&accepts, 1)
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for n := 0; perConn == 0 || n < perConn; n++ {
+					header := make([]byte, 4)
+					if _, err := io.ReadFull(conn, header); err != nil {
+						return
+					}
+					body := make([]byte, binary.BigEndian.Uint32(header))
+					if _, err := io.ReadFull(conn, body); err != nil {
+						return
+					}
+					if _, err := conn.Write(ScrambleTCP(response)); err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	return l.Addr().String(), &accepts
+}
+
+func TestPooledTransportReuse(t *testing.T) {
+	addr, accepts := fakeTCPDevice(t, `{"system":{"get_sysinfo":{"err_code":0}}}`, 0)
+
+	p := NewPooledTransport(0)
+	defer p.Close()
+
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		res, err := p.Query(ctx, addr, CmdGetSysinfo)
+		cancel()
+		if err != nil {
+			t.Fatalf("query %d: unexpected error: %v", i, err)
+		}
+		if len(res) == 0 {
+			t.Fatalf("query %d: empty response", i)
+		}
+	}
+
+	if n := atomic.LoadInt32(accepts); n != 1 {
+		t.Fatalf("got %d connections, want 1", n)
+	}
+}
+
+func TestPooledTransportReconnect(t *testing.T) {
+	// device hangs up after every reply, like older firmware does
+	addr, accepts := fakeTCPDevice(t, `{"system":{"get_sysinfo":{"err_code":0}}}`, 1)
+
+	p := NewPooledTransport(0)
+	defer p.Close()
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := p.Query(ctx, addr, CmdGetSysinfo)
+		cancel()
+		if err != nil {
+			t.Fatalf("query %d: unexpected error: %v", i, err)
+		}
+	}
+
+	if n := atomic.LoadInt32(accepts); n != 3 {
+		t.Fatalf("got %d connections, want 3", n)
+	}
+}
+
+func TestPooledTransportNoRetryOnTimeout(t *testing.T) {
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+
+	// the device answers the first command, then acts on the second without replying
+	var accepts, commands int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&accepts, 1)
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for {
+					header := make([]byte, 4)
+					if _, err := io.ReadFull(conn, header); err != nil {
+						return
+					}
+					body := make([]byte, binary.BigEndian.Uint32(header))
+					if _, err := io.ReadFull(conn, body); err != nil {
+						return
+					}
+					if atomic.AddInt32(&commands, 1) == 1 {
+						_, _ = conn.Write(ScrambleTCP(`{"system":{"get_sysinfo":{"err_code":0}}}`))
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	p := NewPooledTransport(0)
+	p.ReadTimeout = 100 * time.Millisecond
+	defer p.Close()
+
+	if _, err := p.Query(context.Background(), l.Addr().String(), CmdGetSysinfo); err != nil {
+		t.Fatalf("first query: unexpected error: %v", err)
+	}
+	if _, err := p.Query(context.Background(), l.Addr().String(), `{"system":{"reboot":{"delay":1}}}`); err == nil {
+		t.Fatal("second query: expected a timeout")
+	}
+
+	if n := atomic.LoadInt32(&commands); n != 2 {
+		t.Fatalf("device saw %d commands, want 2", n)
+	}
+	if n := atomic.LoadInt32(&accepts); n != 1 {
+		t.Fatalf("got %d connections, want 1", n)
+	}
+}
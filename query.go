@@ -0,0 +1,66 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// QueryResult is the KasaDevice decoded from a batched query along with any per-section errors
+type QueryResult struct {
+	KasaDevice
+
+	// Errors is keyed by "module" when the whole module failed (e.g. "module not support") or "module.method"
+	Errors map[string]error
+}
+
+// Err returns the error for a single section of the query, nil if that section succeeded.
+// Sections which were sent but are missing from the reply return ErrNoResponse.
+func (q *QueryResult) Err(module, method string) error {
+	if err, ok := q.Errors[module]; ok {
+		return err
+	}
+	return q.Errors[module+"."+method]
+}
+
+// Query sends any combination of modules and methods in a single round trip.
+//
+//	r := kasa.NewRequest().
+//		Add("system", "get_sysinfo", nil).
+//		Add("emeter", "get_realtime", nil).
+//		Add("netif", "get_stainfo", nil)
+//	res, err := d.Query(r)
+//
+// The returned error only covers the transport and decoding, check QueryResult.Err for each section.
+func (d *Device) Query(r *Request) (*QueryResult, error) {
+	return d.QueryCtx(context.Background(), r)
+}
+
+func (d *Device) QueryCtx(ctx context.Context, r *Request) (*QueryResult, error) {
+	res, err := d.query(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var q QueryResult
+	if err := json.Unmarshal(res, &q.KasaDevice); err != nil {
+		return nil, err
+	}
+
+	sections, err := parseResponse(res)
+	if err != nil {
+		return nil, err
+	}
+	q.Errors = sections.errors()
+
+	// a section the device skipped is not a success
+	for _, c := range r.calls {
+		if q.Err(c.module, c.method) != nil {
+			continue
+		}
+		if _, err := sections.section(c.module, c.method); errors.Is(err, ErrNoResponse) {
+			q.Errors[c.module+"."+c.method] = err
+		}
+	}
+	return &q, nil
+}
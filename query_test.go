@@ -0,0 +1,95 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestQueryCtx(t *testing.T) {
+	response := `{
+		"system": {"get_sysinfo": {"alias": "kettle", "model": "KP115(US)", "err_code": 0}},
+		"emeter": {"get_realtime": {"power_mw": 1500000, "voltage_mv": 121000, "err_code": 0}},
+		"smartlife.iot.dimmer": {"err_code": -1, "err_msg": "module not support"},
+		"netif": {"get_stainfo": {"err_code": -3, "err_msg": "invalid argument"}}
+	}`
+
+	var sent string
+	md := &Device{
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				sent = cmd
+				return []byte(response), nil
+			},
+		},
+	}
+
+	r := NewRequest().
+		Add("system", "get_sysinfo", nil).
+		Add("emeter", "get_realtime", nil).
+		Add("smartlife.iot.dimmer", "get_dimmer_parameters", nil).
+		Add("netif", "get_stainfo", nil)
+
+	q, err := md.QueryCtx(context.Background(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var modules map[string]map[string]any
+	if err := json.Unmarshal([]byte(sent), &modules); err != nil {
+		t.Fatalf("invalid JSON sent: %v", err)
+	}
+	if len(modules) != 4 {
+		t.Fatalf("expected 4 modules in one request, got %s", sent)
+	}
+
+	if err := q.Err("system", "get_sysinfo"); err != nil {
+		t.Fatalf("unexpected sysinfo error: %v", err)
+	}
+	if q.GetSysinfo.Sysinfo.Alias != "kettle" {
+		t.Fatalf("got alias %q", q.GetSysinfo.Sysinfo.Alias)
+	}
+	if err := q.Err("emeter", "get_realtime"); err != nil {
+		t.Fatalf("unexpected emeter error: %v", err)
+	}
+	if q.Emeter.Realtime.PowerMW != 1500000 {
+		t.Fatalf("got power %d", q.Emeter.Realtime.PowerMW)
+	}
+	if err := q.Err("smartlife.iot.dimmer", "get_dimmer_parameters"); err == nil {
+		t.Fatal("expected module error for dimmer")
+	}
+	if err := q.Err("netif", "get_stainfo"); err == nil {
+		t.Fatal("expected method error for netif")
+	}
+}
+
+func TestQueryCtxMissingSection(t *testing.T) {
+	// an older plug answers sysinfo but drops the emeter and netif sections entirely
+	md := &Device{
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				return []byte(`{"system":{"get_sysinfo":{"alias":"lamp","err_code":0}},"netif":{}}`), nil
+			},
+		},
+	}
+
+	r := NewRequest().
+		Add("system", "get_sysinfo", nil).
+		Add("emeter", "get_realtime", nil).
+		Add("netif", "get_stainfo", nil)
+
+	q, err := md.QueryCtx(context.Background(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := q.Err("system", "get_sysinfo"); err != nil {
+		t.Fatalf("unexpected sysinfo error: %v", err)
+	}
+	if err := q.Err("emeter", "get_realtime"); !errors.Is(err, ErrNoResponse) {
+		t.Fatalf("emeter: got %v, want ErrNoResponse", err)
+	}
+	if err := q.Err("netif", "get_stainfo"); !errors.Is(err, ErrNoResponse) {
+		t.Fatalf("netif: got %v, want ErrNoResponse", err)
+	}
+}
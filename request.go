@@ -0,0 +1,90 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Params are the arguments to a single method
+type Params map[string]any
+
+// Request is a structured command: module -> method -> params, optionally scoped to child outlets.
+// It is marshaled with encoding/json, so aliases, SSIDs and passwords are always escaped correctly.
+//
+//	r := NewRequest().Add("system", "set_dev_alias", Params{"alias": name}).Children(childID)
+type Request struct {
+	childIDs []string
+	calls    []call
+}
+
+type call struct {
+	module string
+	method string
+	params any
+}
+
+// NewRequest starts an empty request
+func NewRequest() *Request {
+	return &Request{}
+}
+
+// Add appends a method call to the request, nil params are sent as {}
+func (r *Request) Add(module, method string, params any) *Request {
+	r.calls = append(r.calls, call{module: module, method: method, params: params})
+	return r
+}
+
+// Children scopes the request to the listed child outlets on multi-relay devices
+func (r *Request) Children(ids ...string) *Request {
+	r.childIDs = append(r.childIDs, ids...)
+	return r
+}
+
+// MarshalJSON produces the wire format: {"context":{"child_ids":[...]},"module":{"method":{...}}}
+func (r *Request) MarshalJSON() ([]byte, error) {
+	out := make(map[string]any, len(r.calls)+1)
+
+	for _, c := range r.calls {
+		m, ok := out[c.module].(map[string]any)
+		if !ok {
+			m = make(map[string]any)
+			out[c.module] = m
+		}
+		if c.params == nil {
+			m[c.method] = struct{}{}
+		} else {
+			m[c.method] = c.params
+		}
+	}
+
+	if len(r.childIDs) > 0 {
+		out["context"] = map[string][]string{"child_ids": r.childIDs}
+	}
+
+	return json.Marshal(out)
+}
+
+// String returns the JSON command, or an empty string if the params cannot be marshaled
+func (r *Request) String() string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (d *Device) query(ctx context.Context, r *Request) ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.sendTCP(ctx, string(b))
+}
+
+func (d *Device) send(ctx context.Context, r *Request) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return d.sendUDP(ctx, string(b))
+}
@@ -0,0 +1,86 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRequestMatchesCmd(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *Request
+		cmd  string
+	}{
+		{"sysinfo", NewRequest().Add("system", "get_sysinfo", nil), CmdGetSysinfo},
+		{"relay", NewRequest().Add("system", "set_relay_state", Params{"state": 1}), fmt.Sprintf(CmdSetRelayState, 1)},
+		{"child relay", NewRequest().Add("system", "set_relay_state", Params{"state": 0}).Children("01"), fmt.Sprintf(CmdSetRelayStateChild, "01", 0)},
+		{"multi relay", NewRequest().Add("system", "set_relay_state", Params{"state": 1}).Children("01", "02"), fmt.Sprintf(CmdSetRelayStateChildMulti, `"01","02"`, 1)},
+		{"daystat", NewRequest().Add("emeter", "get_daystat", Params{"month": 7, "year": 2025}), fmt.Sprintf(CmdEmeterGetMonth, 7, 2025)},
+		{"erase", NewRequest().Add("emeter", "erase_emeter_stat", json.RawMessage("null")), CmdEmeterErase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, want any
+			if err := json.Unmarshal([]byte(tt.req.String()), &got); err != nil {
+				t.Fatalf("invalid JSON produced: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.cmd), &want); err != nil {
+				t.Fatalf("invalid JSON constant: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %s, want %s", tt.req.String(), tt.cmd)
+			}
+		})
+	}
+}
+
+func TestSetAliasEscaping(t *testing.T) {
+	names := []string{
+		`Scot's "Office" Lamp`,
+		`back\slash`,
+		`"},"system":{"reboot":{"delay":1}}}`,
+	}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			var sent string
+			md := &Device{
+				Transport: TransportFuncs{
+					SendFunc: func(ctx context.Context, addr string, cmd string) error {
+						sent = cmd
+						return nil
+					},
+				},
+			}
+
+			if err := md.SetChildAliasCtx(context.Background(), "01", name); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var decoded struct {
+				Context struct {
+					ChildIDs []string `json:"child_ids"`
+				} `json:"context"`
+				System map[string]struct {
+					Alias string `json:"alias"`
+				} `json:"system"`
+			}
+			if err := json.Unmarshal([]byte(sent), &decoded); err != nil {
+				t.Fatalf("invalid JSON produced: %v", err)
+			}
+			if len(decoded.System) != 1 {
+				t.Fatalf("expected only set_dev_alias, got %s", sent)
+			}
+			if got := decoded.System["set_dev_alias"].Alias; got != name {
+				t.Fatalf("got alias %q, want %q", got, name)
+			}
+			if len(decoded.Context.ChildIDs) != 1 || decoded.Context.ChildIDs[0] != "01" {
+				t.Fatalf("bad child context: %s", sent)
+			}
+		})
+	}
+}
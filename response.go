@@ -0,0 +1,157 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ResponseError is a failure reported by the device in its response
+type ResponseError struct {
+	Module string
+	Method string // empty if the whole module failed, usually "module not support"
+	KasaErr
+}
+
+func (e *ResponseError) Error() string {
+	if e.Module == "" {
+		return fmt.Sprintf("kasa error %d: %s", e.ErrCode, e.ErrMsg)
+	}
+	if e.Method == "" {
+		return fmt.Sprintf("kasa error %d in %s: %s", e.ErrCode, e.Module, e.ErrMsg)
+	}
+	return fmt.Sprintf("kasa error %d in %s.%s: %s", e.ErrCode, e.Module, e.Method, e.ErrMsg)
+}
+
+// response is a device reply split by module, each module is left raw until a method is requested
+type response map[string]json.RawMessage
+
+func parseResponse(res []byte) (response, error) {
+	var r response
+	if err := json.Unmarshal(res, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// section returns the reply to module.method after checking the module-level and method-level err_code
+func (r response) section(module, method string) (json.RawMessage, error) {
+	raw, ok := r[module]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoResponse, module)
+	}
+
+	var modErr KasaErr
+	if err := json.Unmarshal(raw, &modErr); err == nil && modErr.ErrCode != 0 {
+		return nil, &ResponseError{Module: module, KasaErr: modErr}
+	}
+
+	var methods map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &methods); err != nil {
+		return nil, err
+	}
+
+	mraw, ok := methods[method]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s.%s", ErrNoResponse, module, method)
+	}
+
+	var methodErr KasaErr
+	if err := json.Unmarshal(mraw, &methodErr); err == nil && methodErr.ErrCode != 0 {
+		return nil, &ResponseError{Module: module, Method: method, KasaErr: methodErr}
+	}
+	return mraw, nil
+}
+
+// errors returns every error in the response keyed by "module" for module-level errors or "module.method"
+func (r response) errors() map[string]error {
+	errs := make(map[string]error)
+	for module, raw := range r {
+		if module == "context" {
+			continue
+		}
+
+		var methods map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &methods); err != nil {
+			continue
+		}
+
+		if _, err := r.section(module, ""); err != nil {
+			if re, ok := err.(*ResponseError); ok && re.Method == "" {
+				errs[module] = err
+				continue
+			}
+		}
+
+		for method := range methods {
+			if method == "err_code" || method == "err_msg" {
+				continue
+			}
+			if _, err := r.section(module, method); err != nil {
+				errs[module+"."+method] = err
+			}
+		}
+	}
+	return errs
+}
+
+// checkResponse returns the first error in a response, used when the caller doesn't need the data
+func checkResponse(res []byte) error {
+	r, err := parseResponse(res)
+	if err != nil {
+		return err
+	}
+
+	errs := r.errors()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	// report the same error each time if there are several
+	keys := make([]string, 0, len(errs))
+	for k := range errs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return errs[keys[0]]
+}
+
+// responseErrors returns the errors in a response keyed by "module" for module-level errors or "module.method"
+func responseErrors(res []byte) (map[string]error, error) {
+	r, err := parseResponse(res)
+	if err != nil {
+		return nil, err
+	}
+	return r.errors(), nil
+}
+
+// call sends the request over TCP, validates every section of the reply,
+// then decodes the reply to the request's first method into v (if v is not nil)
+func (d *Device) call(ctx context.Context, req *Request, v any) error {
+	res, err := d.query(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	r, err := parseResponse(res)
+	if err != nil {
+		return err
+	}
+
+	var first json.RawMessage
+	for i, c := range req.calls {
+		raw, err := r.section(c.module, c.method)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			first = raw
+		}
+	}
+
+	if v == nil || first == nil {
+		return nil
+	}
+	return json.Unmarshal(first, v)
+}
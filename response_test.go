@@ -0,0 +1,107 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResponseValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		call       func(d *Device) error
+		shouldErr  bool
+		wantModule string
+		wantMethod string
+	}{
+		{
+			name:     "child daystat error",
+			response: `{"context":{"child_ids":["01"]},"emeter":{"get_daystat":{"err_code":-3,"err_msg":"invalid argument"}}}`,
+			call: func(d *Device) error {
+				_, err := d.GetEmeterChildMonthCtx(context.Background(), 1, 2026, "01")
+				return err
+			},
+			shouldErr:  true,
+			wantModule: "emeter",
+			wantMethod: "get_daystat",
+		},
+		{
+			name:     "set_mode error",
+			response: `{"system":{"set_mode":{"err_code":-2,"err_msg":"member not support"}}}`,
+			call: func(d *Device) error {
+				return d.SetModeCtx(context.Background(), "bogus")
+			},
+			shouldErr:  true,
+			wantModule: "system",
+			wantMethod: "set_mode",
+		},
+		{
+			name:     "set_mode ok",
+			response: `{"system":{"set_mode":{"err_code":0}}}`,
+			call: func(d *Device) error {
+				return d.SetModeCtx(context.Background(), "count_down")
+			},
+		},
+		{
+			name:     "module not supported",
+			response: `{"smartlife.iot.dimmer":{"err_code":-1,"err_msg":"module not support"}}`,
+			call: func(d *Device) error {
+				_, err := d.GetDimmerParametersCtx(context.Background())
+				return err
+			},
+			shouldErr:  true,
+			wantModule: "smartlife.iot.dimmer",
+		},
+		{
+			name:     "light sensor config",
+			response: `{"smartlife.iot.LAS":{"get_config":{"devs":[{"enable":1,"level_array":[{"name":"cloudy","adc":390,"value":15}]}],"ver":"1.0","err_code":0}}}`,
+			call: func(d *Device) error {
+				c, err := d.GetLightSensorConfigCtx(context.Background())
+				if err != nil {
+					return err
+				}
+				if len(c.Devs) != 1 || c.Devs[0].Levels[0].Name != "cloudy" {
+					return errors.New("light sensor config not decoded")
+				}
+				return nil
+			},
+		},
+		{
+			name:     "missing section",
+			response: `{"system":{"get_sysinfo":{"err_code":0}}}`,
+			call: func(d *Device) error {
+				_, err := d.GetEmeterCtx(context.Background())
+				return err
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(mockQuery(tt.response))
+
+			if !tt.shouldErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if tt.wantModule == "" {
+				return
+			}
+
+			var re *ResponseError
+			if !errors.As(err, &re) {
+				t.Fatalf("expected *ResponseError, got %T: %v", err, err)
+			}
+			if re.Module != tt.wantModule || re.Method != tt.wantMethod {
+				t.Fatalf("got error for %s.%s, want %s.%s", re.Module, re.Method, tt.wantModule, tt.wantMethod)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+)
+
+// The schedule, anti_theft and count_down modules share the same rule methods,
+// only the rule format and the namespace differ. childIDs scope the rules to outlets on multi-relay devices.
+
+func (d *Device) getRules(ctx context.Context, module string, v any, childIDs ...string) error {
+	m, err := d.commonModule(ctx, module)
+	if err != nil {
+		return err
+	}
+	return d.call(ctx, NewRequest().Add(m, "get_rules", nil).Children(childIDs...), v)
+}
+
+func (d *Device) addRule(ctx context.Context, module string, rule any, childIDs ...string) (string, error) {
+	m, err := d.commonModule(ctx, module)
+	if err != nil {
+		return "", err
+	}
+	var added AddRule
+	if err := d.call(ctx, NewRequest().Add(m, "add_rule", rule).Children(childIDs...), &added); err != nil {
+		return "", err
+	}
+	return added.ID, nil
+}
+
+func (d *Device) editRule(ctx context.Context, module string, id string, rule any, childIDs ...string) error {
+	if id == "" {
+		return fmt.Errorf("%s rule ID is required", module)
+	}
+	m, err := d.commonModule(ctx, module)
+	if err != nil {
+		return err
+	}
+	return d.call(ctx, NewRequest().Add(m, "edit_rule", rule).Children(childIDs...), nil)
+}
+
+func (d *Device) deleteRule(ctx context.Context, module string, id string, childIDs ...string) error {
+	if id == "" {
+		return fmt.Errorf("%s rule ID is required", module)
+	}
+	m, err := d.commonModule(ctx, module)
+	if err != nil {
+		return err
+	}
+	return d.call(ctx, NewRequest().Add(m, "delete_rule", Params{"id": id}).Children(childIDs...), nil)
+}
+
+func (d *Device) deleteAllRules(ctx context.Context, module string, childIDs ...string) error {
+	m, err := d.commonModule(ctx, module)
+	if err != nil {
+		return err
+	}
+	return d.call(ctx, NewRequest().Add(m, "delete_all_rules", nil).Children(childIDs...), nil)
+}
+
+func (d *Device) enableRules(ctx context.Context, module string, enable bool) error {
+	m, err := d.commonModule(ctx, module)
+	if err != nil {
+		return err
+	}
+	return d.call(ctx, NewRequest().Add(m, "set_overall_enable", Params{"enable": boolToInt(enable)}), nil)
+}
@@ -0,0 +1,266 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Weekdays is a set of days a rule runs on, bit 0 is Sunday
+type Weekdays uint8
+
+const (
+	Sunday Weekdays = 1 << iota
+	Monday
+	Tuesday
+	Wednesday
+	Thursday
+	Friday
+	Saturday
+
+	Weekend  = Saturday | Sunday
+	Workdays = Monday | Tuesday | Wednesday | Thursday | Friday
+	Everyday = Weekend | Workdays
+)
+
+var dayNames = [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// ParseWeekdays accepts a comma separated list of day names ("mon,wed,fri"), "weekend", "workdays" or "everyday"
+func ParseWeekdays(s string) (Weekdays, error) {
+	var w Weekdays
+	for _, p := range strings.Split(strings.ToLower(s), ",") {
+		p = strings.TrimSpace(p)
+		switch p {
+		case "weekend":
+			w |= Weekend
+			continue
+		case "workdays", "weekdays":
+			w |= Workdays
+			continue
+		case "everyday", "daily", "all":
+			w |= Everyday
+			continue
+		}
+
+		found := false
+		for i := range dayNames {
+			// the full name, or a two or three letter abbreviation of it
+			name := strings.ToLower(time.Weekday(i).String())
+			if p == name || (len(p) >= 2 && len(p) <= 3 && strings.HasPrefix(name, p)) {
+				w |= 1 << i
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown day %q", p)
+		}
+	}
+	return w, nil
+}
+
+func (w Weekdays) String() string {
+	var days []string
+	for i, n := range dayNames {
+		if w&(1<<i) != 0 {
+			days = append(days, n)
+		}
+	}
+	return strings.Join(days, ",")
+}
+
+// MarshalJSON produces the device format, one 0/1 entry per day starting with Sunday
+func (w Weekdays) MarshalJSON() ([]byte, error) {
+	var days [7]int
+	for i := range days {
+		if w&(1<<i) != 0 {
+			days[i] = 1
+		}
+	}
+	return json.Marshal(days)
+}
+
+func (w *Weekdays) UnmarshalJSON(b []byte) error {
+	var days []int
+	if err := json.Unmarshal(b, &days); err != nil {
+		return err
+	}
+	*w = 0
+	for i, d := range days {
+		if d != 0 && i < 7 {
+			*w |= 1 << i
+		}
+	}
+	return nil
+}
+
+// TimeOpt says how a rule's start or end minute is interpreted
+type TimeOpt int
+
+const (
+	TimeNone    TimeOpt = -1 // no end action
+	TimeClock   TimeOpt = 0  // minutes after midnight
+	TimeSunrise TimeOpt = 1  // minutes offset from sunrise
+	TimeSunset  TimeOpt = 2  // minutes offset from sunset
+)
+
+func (t TimeOpt) String() string {
+	switch t {
+	case TimeNone:
+		return "none"
+	case TimeClock:
+		return "clock"
+	case TimeSunrise:
+		return "sunrise"
+	case TimeSunset:
+		return "sunset"
+	}
+	return fmt.Sprintf("TimeOpt(%d)", int(t))
+}
+
+// ScheduleRule is defined by kasa devices
+type ScheduleRule struct {
+	ID       string         `json:"id,omitempty"`
+	Name     string         `json:"name"`
+	Enable   uint           `json:"enable"`
+	Weekdays Weekdays       `json:"wday"`
+	Repeat   uint           `json:"repeat"` // 0 runs once on Year/Month/Day
+	StartOpt TimeOpt        `json:"stime_opt"`
+	StartMin int            `json:"smin"`
+	StartAct int            `json:"sact"` // 0 off, 1 on, 2 toggle
+	EndOpt   TimeOpt        `json:"etime_opt"`
+	EndMin   int            `json:"emin"`
+	EndAct   int            `json:"eact"` // -1 for none
+	Year     int            `json:"year,omitempty"`
+	Month    int            `json:"month,omitempty"`
+	Day      int            `json:"day,omitempty"`
+	Light    *ScheduleLight `json:"s_light,omitempty"` // dimmers and bulbs
+}
+
+// ScheduleLight is the light state a rule sets on dimmers and bulbs
+type ScheduleLight struct {
+	Brightness       int    `json:"brightness"`
+	OnOff            int    `json:"on_off,omitempty"`
+	Mode             string `json:"mode,omitempty"`
+	Hue              int    `json:"hue,omitempty"`
+	Saturation       int    `json:"saturation,omitempty"`
+	ColorTemp        int    `json:"color_temp,omitempty"`
+	TransitionPeriod int    `json:"transition_period,omitempty"`
+}
+
+// Start returns the start time as "HH:MM", or an offset from sunrise/sunset
+func (r *ScheduleRule) Start() string {
+	return formatRuleTime(r.StartOpt, r.StartMin)
+}
+
+// End returns the end time as "HH:MM", an offset from sunrise/sunset, or "" if there is no end action
+func (r *ScheduleRule) End() string {
+	if r.EndOpt == TimeNone || r.EndAct < 0 {
+		return ""
+	}
+	return formatRuleTime(r.EndOpt, r.EndMin)
+}
+
+func formatRuleTime(opt TimeOpt, min int) string {
+	switch opt {
+	case TimeSunrise, TimeSunset:
+		return fmt.Sprintf("%s%+d", opt, min)
+	case TimeNone:
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d", min/60, min%60)
+}
+
+// ParseRuleTime accepts "HH:MM", "sunrise", "sunset", or an offset in minutes such as "sunset-15"
+func ParseRuleTime(s string) (TimeOpt, int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, opt := range []TimeOpt{TimeSunrise, TimeSunset} {
+		rest, ok := strings.CutPrefix(s, opt.String())
+		if !ok {
+			continue
+		}
+		if rest == "" {
+			return opt, 0, nil
+		}
+		var off int
+		if _, err := fmt.Sscanf(rest, "%d", &off); err != nil {
+			return 0, 0, fmt.Errorf("invalid offset %q", rest)
+		}
+		return opt, off, nil
+	}
+
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, 0, fmt.Errorf("invalid time %q (HH:MM, sunrise[+-min] or sunset[+-min])", s)
+	}
+	return TimeClock, h*60 + m, nil
+}
+
+// ScheduleRules is defined by kasa devices
+type ScheduleRules struct {
+	Rules   []ScheduleRule `json:"rule_list"`
+	Enable  uint           `json:"enable"`
+	Version int            `json:"version"`
+	KasaErr
+}
+
+// GetScheduleRules returns the schedule rules set up on a device
+func (d *Device) GetScheduleRules() (*ScheduleRules, error) {
+	return d.GetScheduleRulesCtx(context.Background())
+}
+
+func (d *Device) GetScheduleRulesCtx(ctx context.Context) (*ScheduleRules, error) {
+	var rules ScheduleRules
+	if err := d.getRules(ctx, "schedule", &rules); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// AddScheduleRule adds a rule to the device and returns the ID the device assigned to it
+func (d *Device) AddScheduleRule(r ScheduleRule) (string, error) {
+	return d.AddScheduleRuleCtx(context.Background(), r)
+}
+
+func (d *Device) AddScheduleRuleCtx(ctx context.Context, r ScheduleRule) (string, error) {
+	r.ID = ""
+	return d.addRule(ctx, "schedule", r)
+}
+
+// EditScheduleRule replaces the rule with the same ID
+func (d *Device) EditScheduleRule(r ScheduleRule) error {
+	return d.EditScheduleRuleCtx(context.Background(), r)
+}
+
+func (d *Device) EditScheduleRuleCtx(ctx context.Context, r ScheduleRule) error {
+	return d.editRule(ctx, "schedule", r.ID, r)
+}
+
+// DeleteScheduleRule removes one rule by ID
+func (d *Device) DeleteScheduleRule(id string) error {
+	return d.DeleteScheduleRuleCtx(context.Background(), id)
+}
+
+func (d *Device) DeleteScheduleRuleCtx(ctx context.Context, id string) error {
+	return d.deleteRule(ctx, "schedule", id)
+}
+
+// DeleteAllScheduleRules removes every schedule rule from the device
+func (d *Device) DeleteAllScheduleRules() error {
+	return d.DeleteAllScheduleRulesCtx(context.Background())
+}
+
+func (d *Device) DeleteAllScheduleRulesCtx(ctx context.Context) error {
+	return d.deleteAllRules(ctx, "schedule")
+}
+
+// EnableScheduleRules turns the whole schedule on or off without touching the individual rules
+func (d *Device) EnableScheduleRules(enable bool) error {
+	return d.EnableScheduleRulesCtx(context.Background(), enable)
+}
+
+func (d *Device) EnableScheduleRulesCtx(ctx context.Context, enable bool) error {
+	return d.enableRules(ctx, "schedule", enable)
+}
@@ -0,0 +1,137 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWeekdays(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Weekdays
+		wire string
+	}{
+		{"mon,wed,fri", Monday | Wednesday | Friday, "[0,1,0,1,0,1,0]"},
+		{"weekend", Weekend, "[1,0,0,0,0,0,1]"},
+		{"everyday", Everyday, "[1,1,1,1,1,1,1]"},
+		{"Tuesday, thu", Tuesday | Thursday, "[0,0,1,0,1,0,0]"},
+		{"su,sa", Weekend, "[1,0,0,0,0,0,1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			w, err := ParseWeekdays(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if w != tt.want {
+				t.Fatalf("got %s, want %s", w, tt.want)
+			}
+			b, _ := json.Marshal(w)
+			if string(b) != tt.wire {
+				t.Fatalf("got %s, want %s", b, tt.wire)
+			}
+			var back Weekdays
+			if err := json.Unmarshal(b, &back); err != nil || back != w {
+				t.Fatalf("round trip gave %s %v", back, err)
+			}
+		})
+	}
+
+	for _, bad := range []string{"someday", "monkey", "tuesdayz", "wedge", "t", "mond"} {
+		if _, err := ParseWeekdays(bad); err == nil {
+			t.Fatalf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestParseRuleTime(t *testing.T) {
+	tests := []struct {
+		in  string
+		opt TimeOpt
+		min int
+		err bool
+	}{
+		{"06:30", TimeClock, 390, false},
+		{"sunset", TimeSunset, 0, false},
+		{"sunset-15", TimeSunset, -15, false},
+		{"Sunrise+20", TimeSunrise, 20, false},
+		{"25:00", 0, 0, true},
+		{"noon", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			opt, min, err := ParseRuleTime(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v", err)
+			}
+			if !tt.err && (opt != tt.opt || min != tt.min) {
+				t.Fatalf("got %s %d", opt, min)
+			}
+		})
+	}
+}
+
+func TestScheduleNamespace(t *testing.T) {
+	tests := []struct {
+		model  string
+		module string
+	}{
+		{"HS103(US)", "schedule"},
+		{"HS220(US)", "smartlife.iot.common.schedule"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			var sent []string
+			res := `{"` + tt.module + `":{"get_rules":{"rule_list":[{"id":"A1","name":"porch","enable":1,"wday":[0,1,1,1,1,1,0],"stime_opt":2,"smin":-15,"sact":1,"etime_opt":0,"emin":1380,"eact":0,"repeat":1}],"enable":1,"version":2,"err_code":0}}}`
+			d := ruleDevice(tt.model, res, &sent)
+
+			rules, err := d.GetScheduleRulesCtx(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(sent) != 1 || !strings.Contains(sent[0], `"`+tt.module+`"`) {
+				t.Fatalf("sent %v", sent)
+			}
+			if len(rules.Rules) != 1 {
+				t.Fatalf("got %d rules", len(rules.Rules))
+			}
+			r := rules.Rules[0]
+			if r.Weekdays != Workdays || r.Start() != "sunset-15" || r.End() != "23:00" {
+				t.Fatalf("got %s %s-%s", r.Weekdays, r.Start(), r.End())
+			}
+		})
+	}
+}
+
+func TestAddScheduleRule(t *testing.T) {
+	var sent []string
+	d := ruleDevice("HS220(US)", `{"smartlife.iot.common.schedule":{"add_rule":{"id":"NEW1","err_code":0}}}`, &sent)
+
+	id, err := d.AddScheduleRuleCtx(context.Background(), ScheduleRule{
+		Name:     "morning",
+		Enable:   1,
+		Weekdays: Workdays,
+		Repeat:   1,
+		StartMin: 6*60 + 30,
+		StartAct: 1,
+		EndOpt:   TimeNone,
+		EndAct:   -1,
+		Light:    &ScheduleLight{Brightness: 40},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "NEW1" {
+		t.Fatalf("got id %q", id)
+	}
+	for _, want := range []string{`"wday":[0,1,1,1,1,1,0]`, `"smin":390`, `"s_light":{"brightness":40}`} {
+		if !strings.Contains(sent[0], want) {
+			t.Fatalf("%s missing from %s", want, sent[0])
+		}
+	}
+}
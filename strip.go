@@ -0,0 +1,247 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	lightStrip     = "smartlife.iot.lightStrip"
+	lightingEffect = "smartlife.iot.lighting_effect"
+)
+
+// EffectState is the effect a light strip is running, as reported in sysinfo
+type EffectState struct {
+	Enable     int    `json:"enable"`
+	Name       string `json:"name"`
+	ID         string `json:"id"`
+	Brightness int    `json:"brightness"`
+	Custom     int    `json:"custom"`
+}
+
+// LightingEffect is a light strip effect definition. Sequence effects step the colors in Sequence along the strip;
+// random effects pick colors from the ranges, starting from InitStates
+type LightingEffect struct {
+	ID                string   `json:"id"`
+	Name              string   `json:"name"`
+	Custom            int      `json:"custom"`
+	Enable            int      `json:"enable"`
+	Brightness        int      `json:"brightness"`
+	Type              string   `json:"type"` // "sequence", "random" or "pulse"
+	Segments          []int    `json:"segments"`
+	ExpansionStrategy int      `json:"expansion_strategy"`
+	Duration          int      `json:"duration"`
+	Transition        int      `json:"transition"`
+	Direction         int      `json:"direction,omitempty"`
+	Spread            int      `json:"spread,omitempty"`
+	RepeatTimes       int      `json:"repeat_times"`
+	Sequence          [][3]int `json:"sequence,omitempty"` // hue, saturation, brightness
+	HueRange          []int    `json:"hue_range,omitempty"`
+	SaturationRange   []int    `json:"saturation_range,omitempty"`
+	BrightnessRange   []int    `json:"brightness_range,omitempty"`
+	TransitionRange   []int    `json:"transition_range,omitempty"`
+	InitStates        [][3]int `json:"init_states,omitempty"`
+	Fadeoff           int      `json:"fadeoff,omitempty"`
+	RandomSeed        int      `json:"random_seed,omitempty"`
+	Backgrounds       [][3]int `json:"backgrounds,omitempty"`
+}
+
+// builtinEffects are definitions of some of the effects built into the Kasa app. The set is partial:
+// Bubbling Cauldron, Christmas, Grandma's Christmas Lights, Hanukkah, Haunted Mansion, Icicle, Lightning,
+// Raindrop, Spring, Sunrise, Sunset and Valentines are missing. Start those from the app, or pass their
+// definition to SetLightingEffect.
+var builtinEffects = map[string]LightingEffect{
+	"Aurora": {
+		ID: "xqUxDhbAhNLqulcuRMyPBmVGyTOyEMEu", Name: "Aurora", Enable: 1, Brightness: 100,
+		Type: "sequence", Segments: []int{0}, ExpansionStrategy: 1,
+		Transition: 1500, Direction: 4, Spread: 7,
+		Sequence: [][3]int{{120, 100, 100}, {240, 100, 100}, {260, 100, 100}, {280, 100, 100}},
+	},
+	"Candy Cane": {
+		ID: "HCOttllMkNffeHjEOLEgrFJjbzQHoxEJ", Name: "Candy Cane", Enable: 1, Brightness: 100,
+		Type: "sequence", Segments: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, ExpansionStrategy: 1,
+		Transition: 200, Direction: 1, Spread: 1,
+		Sequence: [][3]int{
+			{0, 0, 100}, {0, 0, 100}, {360, 81, 100}, {0, 0, 100},
+			{0, 0, 100}, {360, 81, 100}, {360, 81, 100}, {0, 0, 100},
+			{0, 0, 100}, {360, 81, 100}, {360, 81, 100}, {360, 81, 100},
+			{360, 81, 100}, {0, 0, 100}, {0, 0, 100}, {360, 81, 100},
+		},
+	},
+	"Ocean": {
+		ID: "oJjUMosgEMrdumfPxMhivNXnvHCCqxIz", Name: "Ocean", Enable: 1, Brightness: 30,
+		Type: "sequence", Segments: []int{0}, ExpansionStrategy: 1,
+		Transition: 2000, Direction: 3, Spread: 16,
+		Sequence: [][3]int{{198, 84, 30}, {198, 70, 30}, {198, 10, 30}},
+	},
+	"Rainbow": {
+		ID: "izRhLCQNcDzIKdpMPqSTtBMuAIoreAuT", Name: "Rainbow", Enable: 1, Brightness: 100,
+		Type: "sequence", Segments: []int{0}, ExpansionStrategy: 1,
+		Transition: 1500, Direction: 1, Spread: 12,
+		Sequence: [][3]int{{0, 100, 100}, {100, 100, 100}, {200, 100, 100}, {300, 100, 100}},
+	},
+	"Flicker": {
+		ID: "bCTItKETDFfrKANolgldxfgOakaarARs", Name: "Flicker", Enable: 1, Brightness: 100,
+		Type: "random", Segments: []int{1}, ExpansionStrategy: 1,
+		HueRange: []int{30, 40}, SaturationRange: []int{100, 100}, BrightnessRange: []int{50, 100},
+		TransitionRange: []int{375, 500}, InitStates: [][3]int{{30, 81, 80}},
+	},
+}
+
+// EffectNames lists the built-in effects, a partial set of the app's, which BuiltinEffect can return
+func EffectNames() []string {
+	names := make([]string, 0, len(builtinEffects))
+	for n := range builtinEffects {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuiltinEffect returns a copy of a built-in effect, the name is not case sensitive
+func BuiltinEffect(name string) (LightingEffect, bool) {
+	for n, e := range builtinEffects {
+		if strings.EqualFold(n, name) {
+			e.Segments = slices.Clone(e.Segments)
+			e.Sequence = slices.Clone(e.Sequence)
+			e.HueRange = slices.Clone(e.HueRange)
+			e.SaturationRange = slices.Clone(e.SaturationRange)
+			e.BrightnessRange = slices.Clone(e.BrightnessRange)
+			e.TransitionRange = slices.Clone(e.TransitionRange)
+			e.InitStates = slices.Clone(e.InitStates)
+			e.Backgrounds = slices.Clone(e.Backgrounds)
+			return e, true
+		}
+	}
+	return LightingEffect{}, false
+}
+
+// StripZone is a run of light strip zones set to one color, on the wire [first, last, hue, saturation, brightness, color_temp]
+type StripZone struct {
+	First      int
+	Last       int
+	Hue        int
+	Saturation int
+	Brightness int
+	ColorTemp  int
+}
+
+func (z StripZone) MarshalJSON() ([]byte, error) {
+	return json.Marshal([6]int{z.First, z.Last, z.Hue, z.Saturation, z.Brightness, z.ColorTemp})
+}
+
+func (z *StripZone) UnmarshalJSON(b []byte) error {
+	var a []int
+	if err := json.Unmarshal(b, &a); err != nil {
+		return err
+	}
+	if len(a) < 5 {
+		return fmt.Errorf("short zone %s", b)
+	}
+	z.First, z.Last, z.Hue, z.Saturation, z.Brightness = a[0], a[1], a[2], a[3], a[4]
+	if len(a) > 5 {
+		z.ColorTemp = a[5]
+	}
+	return nil
+}
+
+// StripState is the result of the light strip's get_light_state
+type StripState struct {
+	OnOff  int         `json:"on_off"`
+	Mode   string      `json:"mode"`
+	Length int         `json:"length"`
+	Groups []StripZone `json:"groups"`
+	KasaErr
+}
+
+// GetStripState returns the colors of a light strip's zones
+func (d *Device) GetStripState() (*StripState, error) {
+	return d.GetStripStateCtx(context.Background())
+}
+
+func (d *Device) GetStripStateCtx(ctx context.Context) (*StripState, error) {
+	var s StripState
+	if err := d.call(ctx, NewRequest().Add(lightStrip, "get_light_state", nil), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetStripZones colors runs of zones, stopping any running effect
+func (d *Device) SetStripZones(zones []StripZone, transition time.Duration) error {
+	return d.SetStripZonesCtx(context.Background(), zones, transition)
+}
+
+func (d *Device) SetStripZonesCtx(ctx context.Context, zones []StripZone, transition time.Duration) error {
+	if len(zones) == 0 {
+		return fmt.Errorf("no zones")
+	}
+	s, err := d.GetSettingsCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if !s.Capabilities().Has(CapLightStrip) {
+		return fmt.Errorf("%s does not support %s", s.Model, CapLightStrip)
+	}
+	for _, z := range zones {
+		if z.First < 0 || z.Last < z.First || (s.Length > 0 && z.Last >= int(s.Length)) {
+			return fmt.Errorf("zones %d-%d out of range, the strip has %d", z.First, z.Last, s.Length)
+		}
+	}
+
+	return d.call(ctx, NewRequest().Add(lightStrip, "set_light_state", Params{
+		"groups":     zones,
+		"transition": int(transition / time.Millisecond),
+	}), nil)
+}
+
+// SetLightingEffect starts an effect on a light strip
+func (d *Device) SetLightingEffect(e LightingEffect) error {
+	return d.SetLightingEffectCtx(context.Background(), e)
+}
+
+func (d *Device) SetLightingEffectCtx(ctx context.Context, e LightingEffect) error {
+	if e.Name == "" || e.ID == "" {
+		return fmt.Errorf("an effect needs a name and an id")
+	}
+	if e.Brightness < 0 || e.Brightness > 100 {
+		return fmt.Errorf("brightness must be 0-100, got %d", e.Brightness)
+	}
+	return d.call(ctx, NewRequest().Add(lightingEffect, "set_lighting_effect", e), nil)
+}
+
+// SetBuiltinEffect starts one of the built-in effects by name
+func (d *Device) SetBuiltinEffect(name string) error {
+	return d.SetBuiltinEffectCtx(context.Background(), name)
+}
+
+func (d *Device) SetBuiltinEffectCtx(ctx context.Context, name string) error {
+	e, ok := BuiltinEffect(name)
+	if !ok {
+		return fmt.Errorf("unknown effect %q, try one of: %s", name, strings.Join(EffectNames(), ", "))
+	}
+	return d.SetLightingEffectCtx(ctx, e)
+}
+
+// StopLightingEffect stops the running effect, the strip keeps its last colors
+func (d *Device) StopLightingEffect() error {
+	return d.StopLightingEffectCtx(context.Background())
+}
+
+func (d *Device) StopLightingEffectCtx(ctx context.Context) error {
+	s, err := d.GetSettingsCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if s.EffectState == nil {
+		return fmt.Errorf("%s has no lighting effects", s.Model)
+	}
+	state := *s.EffectState
+	state.Enable = 0
+	return d.call(ctx, NewRequest().Add(lightingEffect, "set_lighting_effect", state), nil)
+}
@@ -0,0 +1,76 @@
+package kasa
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	stripSysinfo = `{"model":"KL430(US)","mic_type":"IOT.SMARTBULB","length":16,"lighting_effect_state":{"enable":1,"name":"Aurora","id":"xqUxDhbAhNLqulcuRMyPBmVGyTOyEMEu","brightness":100,"custom":0},"err_code":0}`
+	stripReply   = `{"smartlife.iot.lightStrip":{"set_light_state":{"err_code":0}},"smartlife.iot.lighting_effect":{"set_lighting_effect":{"err_code":0}}}`
+)
+
+func TestStripZones(t *testing.T) {
+	tests := []struct {
+		name      string
+		zones     []StripZone
+		want      string
+		shouldErr bool
+	}{
+		{"two runs", []StripZone{{0, 7, 0, 100, 100, 0}, {8, 15, 120, 100, 100, 0}},
+			`{"smartlife.iot.lightStrip":{"set_light_state":{"groups":[[0,7,0,100,100,0],[8,15,120,100,100,0]],"transition":500}}}`, false},
+		{"past the end", []StripZone{{8, 16, 0, 100, 100, 0}}, "", true},
+		{"backwards", []StripZone{{7, 3, 0, 100, 100, 0}}, "", true},
+		{"none", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			err := sysinfoDevice(stripSysinfo, stripReply, &sent).SetStripZonesCtx(context.Background(), tt.zones, 500*time.Millisecond)
+			if tt.shouldErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sent[0] != tt.want {
+				t.Fatalf("sent %s, want %s", sent[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestStripEffects(t *testing.T) {
+	var sent []string
+	d := sysinfoDevice(stripSysinfo, stripReply, &sent)
+
+	if err := d.SetBuiltinEffectCtx(context.Background(), "candy cane"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(sent[0], `{"smartlife.iot.lighting_effect":{"set_lighting_effect":{"id":"HCOttllMkNffeHjEOLEgrFJjbzQHoxEJ","name":"Candy Cane",`) {
+		t.Fatalf("sent %s", sent[0])
+	}
+	if err := d.SetBuiltinEffectCtx(context.Background(), "disco"); err == nil {
+		t.Fatal("expected an error for an unknown effect")
+	}
+
+	if err := d.StopLightingEffectCtx(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"smartlife.iot.lighting_effect":{"set_lighting_effect":{"enable":0,"name":"Aurora","id":"xqUxDhbAhNLqulcuRMyPBmVGyTOyEMEu","brightness":100,"custom":0}}}`
+	if sent[len(sent)-1] != want {
+		t.Fatalf("sent %s, want %s", sent[len(sent)-1], want)
+	}
+
+	e, _ := BuiltinEffect("Aurora")
+	e.Segments[0] = 9
+	if again, _ := BuiltinEffect("Aurora"); again.Segments[0] != 0 {
+		t.Fatal("BuiltinEffect returned a shared slice")
+	}
+}
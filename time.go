@@ -0,0 +1,298 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DeviceTime is the wall clock reported by get_time, in the device's own zone
+type DeviceTime struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"mday"`
+	Hour  int `json:"hour"`
+	Min   int `json:"min"`
+	Sec   int `json:"sec"`
+	KasaErr
+}
+
+// Time places the device's wall clock in loc; use the zone from GetTimezone
+func (t *DeviceTime) Time(loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Date(t.Year, time.Month(t.Month), t.Day, t.Hour, t.Min, t.Sec, 0, loc)
+}
+
+// DeviceTimezone is the result of get_timezone
+type DeviceTimezone struct {
+	Index int `json:"index"`
+	KasaErr
+}
+
+// Name is the IANA name for the device's timezone index
+func (z *DeviceTimezone) Name() string {
+	name, _ := TimezoneName(z.Index)
+	return name
+}
+
+// Location loads the device's timezone
+func (z *DeviceTimezone) Location() (*time.Location, error) {
+	name := z.Name()
+	if name == "" {
+		return nil, fmt.Errorf("unknown timezone index %d", z.Index)
+	}
+	return time.LoadLocation(name)
+}
+
+// timezones is the Kasa timezone table, in index order
+var timezones = [...]string{
+	"Etc/GMT+12",
+	"Pacific/Samoa",
+	"US/Hawaii",
+	"US/Alaska",
+	"Mexico/BajaNorte",
+	"Etc/GMT+8",
+	"PST8PDT",
+	"US/Arizona",
+	"America/Mazatlan",
+	"MST",
+	"MST7MDT",
+	"Mexico/General",
+	"Etc/GMT+6",
+	"CST6CDT",
+	"America/Monterrey",
+	"Canada/Saskatchewan",
+	"America/Bogota",
+	"EST5EDT",
+	"America/Indiana/Indianapolis",
+	"America/Caracas",
+	"America/Asuncion",
+	"America/Cuiaba",
+	"America/Halifax",
+	"America/Manaus",
+	"America/Santiago",
+	"America/St_Johns",
+	"America/Sao_Paulo",
+	"America/Argentina/Buenos_Aires",
+	"America/Cayenne",
+	"America/Godthab",
+	"America/Montevideo",
+	"America/Bahia",
+	"Etc/GMT+2",
+	"Atlantic/Azores",
+	"Atlantic/Cape_Verde",
+	"Africa/Casablanca",
+	"UTC",
+	"GB",
+	"Africa/Monrovia",
+	"Europe/Amsterdam",
+	"Europe/Belgrade",
+	"Europe/Brussels",
+	"Europe/Sarajevo",
+	"Africa/Lagos",
+	"Africa/Windhoek",
+	"Asia/Amman",
+	"Europe/Athens",
+	"Asia/Beirut",
+	"Africa/Cairo",
+	"Asia/Damascus",
+	"EET",
+	"Africa/Harare",
+	"Europe/Helsinki",
+	"Asia/Istanbul",
+	"Asia/Jerusalem",
+	"Europe/Kaliningrad",
+	"Africa/Tripoli",
+	"Asia/Baghdad",
+	"Asia/Kuwait",
+	"Europe/Minsk",
+	"Europe/Moscow",
+	"Africa/Nairobi",
+	"Asia/Tehran",
+	"Asia/Muscat",
+	"Asia/Baku",
+	"Europe/Samara",
+	"Indian/Mauritius",
+	"Asia/Tbilisi",
+	"Asia/Yerevan",
+	"Asia/Kabul",
+	"Asia/Tashkent",
+	"Asia/Yekaterinburg",
+	"Asia/Karachi",
+	"Asia/Kolkata",
+	"Asia/Colombo",
+	"Asia/Kathmandu",
+	"Asia/Almaty",
+	"Asia/Dhaka",
+	"Asia/Novosibirsk",
+	"Asia/Rangoon",
+	"Asia/Bangkok",
+	"Asia/Krasnoyarsk",
+	"Asia/Chongqing",
+	"Asia/Irkutsk",
+	"Asia/Singapore",
+	"Australia/Perth",
+	"Asia/Taipei",
+	"Asia/Ulaanbaatar",
+	"Asia/Tokyo",
+	"Asia/Seoul",
+	"Asia/Yakutsk",
+	"Australia/Adelaide",
+	"Australia/Darwin",
+	"Australia/Brisbane",
+	"Australia/Canberra",
+	"Pacific/Guam",
+	"Australia/Hobart",
+	"Antarctica/DumontDUrville",
+	"Asia/Magadan",
+	"Asia/Srednekolymsk",
+	"Etc/GMT-11",
+	"Asia/Anadyr",
+	"Pacific/Auckland",
+	"Etc/GMT-12",
+	"Pacific/Fiji",
+	"Pacific/Tongatapu",
+	"Pacific/Apia",
+	"Pacific/Kiritimati",
+}
+
+// timezoneAliases maps common IANA names onto the legacy names used in the table
+var timezoneAliases = map[string]int{
+	"Pacific/Honolulu":    2,
+	"America/Anchorage":   3,
+	"America/Los_Angeles": 6,
+	"America/Phoenix":     7,
+	"America/Denver":      10,
+	"America/Chicago":     13,
+	"America/New_York":    17,
+	"Etc/UTC":             36,
+	"Europe/London":       37,
+	"Asia/Calcutta":       73,
+	"Asia/Shanghai":       82,
+	"Australia/Sydney":    94,
+	"Australia/Melbourne": 94,
+}
+
+// TimezoneName returns the IANA name for a Kasa timezone index
+func TimezoneName(index int) (string, bool) {
+	if index < 0 || index >= len(timezones) {
+		return "", false
+	}
+	return timezones[index], true
+}
+
+// TimezoneIndex finds the Kasa timezone index for loc. Zones not in the table match the first entry with the same offsets in January and July, so daylight saving follows along.
+func TimezoneIndex(loc *time.Location) (int, error) {
+	if loc == nil {
+		return 0, fmt.Errorf("no location")
+	}
+	for i, name := range timezones {
+		if name == loc.String() {
+			return i, nil
+		}
+	}
+	if i, ok := timezoneAliases[loc.String()]; ok {
+		return i, nil
+	}
+
+	year := time.Now().Year()
+	jan := time.Date(year, time.January, 1, 12, 0, 0, 0, time.UTC)
+	jul := time.Date(year, time.July, 1, 12, 0, 0, 0, time.UTC)
+	_, wantJan := jan.In(loc).Zone()
+	_, wantJul := jul.In(loc).Zone()
+
+	for i, name := range timezones {
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			continue
+		}
+		_, offJan := jan.In(l).Zone()
+		_, offJul := jul.In(l).Zone()
+		if offJan == wantJan && offJul == wantJul {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no kasa timezone matches %s", loc)
+}
+
+// timeModule is "time" everywhere but on bulbs
+func (d *Device) timeModule(ctx context.Context) (string, error) {
+	c, err := d.CapabilitiesCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+	if c.Has(CapBulb) {
+		return "smartlife.iot.common.timesetting", nil
+	}
+	return "time", nil
+}
+
+// GetTime returns the device's wall clock
+func (d *Device) GetTime() (*DeviceTime, error) {
+	return d.GetTimeCtx(context.Background())
+}
+
+func (d *Device) GetTimeCtx(ctx context.Context) (*DeviceTime, error) {
+	m, err := d.timeModule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var t DeviceTime
+	if err := d.call(ctx, NewRequest().Add(m, "get_time", nil), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetTimezone returns the device's timezone index
+func (d *Device) GetTimezone() (*DeviceTimezone, error) {
+	return d.GetTimezoneCtx(context.Background())
+}
+
+func (d *Device) GetTimezoneCtx(ctx context.Context) (*DeviceTimezone, error) {
+	m, err := d.timeModule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var z DeviceTimezone
+	if err := d.call(ctx, NewRequest().Add(m, "get_timezone", nil), &z); err != nil {
+		return nil, err
+	}
+	return &z, nil
+}
+
+// SetTimezone sets the device's clock to t and its zone to t's location
+func (d *Device) SetTimezone(t time.Time) error {
+	return d.SetTimezoneCtx(context.Background(), t)
+}
+
+func (d *Device) SetTimezoneCtx(ctx context.Context, t time.Time) error {
+	index, err := TimezoneIndex(t.Location())
+	if err != nil {
+		return err
+	}
+	m, err := d.timeModule(ctx)
+	if err != nil {
+		return err
+	}
+	return d.call(ctx, NewRequest().Add(m, "set_timezone", Params{
+		"year":  t.Year(),
+		"month": int(t.Month()),
+		"mday":  t.Day(),
+		"hour":  t.Hour(),
+		"min":   t.Minute(),
+		"sec":   t.Second(),
+		"index": index,
+	}), nil)
+}
+
+// SyncTime sets the device's clock and zone from the local host
+func (d *Device) SyncTime() error {
+	return d.SyncTimeCtx(context.Background())
+}
+
+func (d *Device) SyncTimeCtx(ctx context.Context) error {
+	return d.SetTimezoneCtx(ctx, time.Now())
+}
@@ -0,0 +1,89 @@
+package kasa
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimezoneIndex(t *testing.T) {
+	tests := []struct {
+		zone string
+		want int
+	}{
+		{"PST8PDT", 6},
+		{"America/Los_Angeles", 6},
+		{"America/Chicago", 13},
+		{"UTC", 36},
+		{"Europe/London", 37},
+		{"Asia/Tokyo", 88},
+		{"Europe/Paris", 39},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.zone, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.zone)
+			if err != nil {
+				t.Skipf("no zoneinfo for %s: %v", tt.zone, err)
+			}
+			got, err := TimezoneIndex(loc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %d (%s), want %d", got, timezones[got], tt.want)
+			}
+		})
+	}
+
+	if _, ok := TimezoneName(len(timezones)); ok {
+		t.Fatal("expected no name past the end of the table")
+	}
+}
+
+func TestTimeNamespace(t *testing.T) {
+	tests := []struct {
+		model  string
+		module string
+	}{
+		{"HS103(US)", "time"},
+		{"HS220(US)", "time"},
+		{"KL130(US)", "smartlife.iot.common.timesetting"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			var sent []string
+			d := ruleDevice(tt.model, `{"`+tt.module+`":{"get_time":{"year":2024,"month":3,"mday":10,"hour":1,"min":59,"sec":30,"err_code":0}}}`, &sent)
+
+			dt, err := d.GetTimeCtx(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := time.Date(2024, time.March, 10, 1, 59, 30, 0, time.UTC)
+			if got := dt.Time(nil); !got.Equal(want) {
+				t.Fatalf("got %s, want %s", got, want)
+			}
+			if sent[0] != `{"`+tt.module+`":{"get_time":{}}}` {
+				t.Fatalf("sent %s", sent[0])
+			}
+		})
+	}
+}
+
+func TestSetTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+
+	var sent []string
+	d := ruleDevice("HS103(US)", `{"time":{"set_timezone":{"err_code":0}}}`, &sent)
+	if err := d.SetTimezoneCtx(context.Background(), time.Date(2024, time.July, 4, 18, 30, 5, 0, loc)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"time":{"set_timezone":{"hour":18,"index":13,"mday":4,"min":30,"month":7,"sec":5,"year":2024}}}`
+	if sent[0] != want {
+		t.Fatalf("sent %s, want %s", sent[0], want)
+	}
+}
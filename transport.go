@@ -0,0 +1,193 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Transport carries commands to a device.
+// Query is request/response (TCP by default), Send is fire-and-forget (UDP by default).
+// addr is host:port, cmd is the plaintext JSON command; scrambling is the transport's job.
+type Transport interface {
+	Query(ctx context.Context, addr string, cmd string) ([]byte, error)
+	Send(ctx context.Context, addr string, cmd string) error
+}
+
+// TransportFuncs adapts a pair of functions to the Transport interface, handy for tests and bridges
+type TransportFuncs struct {
+	QueryFunc func(ctx context.Context, addr string, cmd string) ([]byte, error)
+	SendFunc  func(ctx context.Context, addr string, cmd string) error
+}
+
+func (t TransportFuncs) Query(ctx context.Context, addr string, cmd string) ([]byte, error) {
+	if t.QueryFunc == nil {
+		return nil, errors.New("kasa: transport does not implement Query")
+	}
+	return t.QueryFunc(ctx, addr, cmd)
+}
+
+func (t TransportFuncs) Send(ctx context.Context, addr string, cmd string) error {
+	if t.SendFunc == nil {
+		return errors.New("kasa: transport does not implement Send")
+	}
+	return t.SendFunc(ctx, addr, cmd)
+}
+
+// DefaultTransport is used by any Device which has not been given a Transport
+var DefaultTransport Transport = &NetTransport{}
+
+// Middleware wraps a Transport to add behavior (logging, metrics, retries...)
+type Middleware func(Transport) Transport
+
+// Chain wraps t in the middleware, the first middleware listed is the outermost
+func Chain(t Transport, mw ...Middleware) Transport {
+	for i := len(mw) - 1; i >= 0; i-- {
+		t = mw[i](t)
+	}
+	return t
+}
+
+// TraceMiddleware logs every command and response
+func TraceMiddleware(l kasalogger) Middleware {
+	return func(next Transport) Transport {
+		return TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				l.Printf("kasa query %s: %s", addr, cmd)
+				res, err := next.Query(ctx, addr, cmd)
+				if err != nil {
+					l.Printf("kasa query %s failed: %s", addr, err.Error())
+					return nil, err
+				}
+				l.Printf("kasa response %s: %s", addr, res)
+				return res, nil
+			},
+			SendFunc: func(ctx context.Context, addr string, cmd string) error {
+				l.Printf("kasa send %s: %s", addr, cmd)
+				err := next.Send(ctx, addr, cmd)
+				if err != nil {
+					l.Printf("kasa send %s failed: %s", addr, err.Error())
+				}
+				return err
+			},
+		}
+	}
+}
+
+// MetricsMiddleware calls observe after every command; op is "query" or "send"
+func MetricsMiddleware(observe func(op string, addr string, elapsed time.Duration, err error)) Middleware {
+	return func(next Transport) Transport {
+		return TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				start := time.Now()
+				res, err := next.Query(ctx, addr, cmd)
+				observe("query", addr, time.Since(start), err)
+				return res, err
+			},
+			SendFunc: func(ctx context.Context, addr string, cmd string) error {
+				start := time.Now()
+				err := next.Send(ctx, addr, cmd)
+				observe("send", addr, time.Since(start), err)
+				return err
+			},
+		}
+	}
+}
+
+// RetryMiddleware retries failed commands up to attempts times in total, doubling backoff between each try
+func RetryMiddleware(attempts int, backoff time.Duration) Middleware {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	return func(next Transport) Transport {
+		return TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				var res []byte
+				err := retry(ctx, attempts, backoff, func() error {
+					var err error
+					res, err = next.Query(ctx, addr, cmd)
+					return err
+				})
+				return res, err
+			},
+			SendFunc: func(ctx context.Context, addr string, cmd string) error {
+				return retry(ctx, attempts, backoff, func() error {
+					return next.Send(ctx, addr, cmd)
+				})
+			},
+		}
+	}
+}
+
+func retry(ctx context.Context, attempts int, backoff time.Duration, f func() error) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = f(); err == nil {
+			return nil
+		}
+		// the device answered, sending it again won't change its mind
+		if errors.Is(err, ErrDevice) || i == attempts-1 {
+			break
+		}
+		if err := sleepCtx(ctx, backoff); err != nil {
+			return err
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RateLimitMiddleware spaces commands at least interval apart.
+// The limit is shared by every device using the returned transport.
+func RateLimitMiddleware(interval time.Duration) Middleware {
+	return func(next Transport) Transport {
+		var mu sync.Mutex
+		var last time.Time
+
+		wait := func(ctx context.Context) error {
+			mu.Lock()
+			now := time.Now()
+			slot := last.Add(interval)
+			if slot.Before(now) {
+				slot = now
+			}
+			last = slot
+			mu.Unlock()
+
+			return sleepCtx(ctx, time.Until(slot))
+		}
+
+		return TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				if err := wait(ctx); err != nil {
+					return nil, err
+				}
+				return next.Query(ctx, addr, cmd)
+			},
+			SendFunc: func(ctx context.Context, addr string, cmd string) error {
+				if err := wait(ctx); err != nil {
+					return err
+				}
+				return next.Send(ctx, addr, cmd)
+			},
+		}
+	}
+}
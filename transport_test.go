@@ -0,0 +1,138 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+
+	mark := func(name string) Middleware {
+		return func(next Transport) Transport {
+			return TransportFuncs{
+				SendFunc: func(ctx context.Context, addr string, cmd string) error {
+					order = append(order, name)
+					return next.Send(ctx, addr, cmd)
+				},
+			}
+		}
+	}
+
+	base := TransportFuncs{
+		SendFunc: func(ctx context.Context, addr string, cmd string) error {
+			order = append(order, "base")
+			return nil
+		},
+	}
+
+	tr := Chain(base, mark("outer"), mark("inner"))
+	if err := tr.Send(context.Background(), "127.0.0.1:9999", "{}"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"outer", "inner", "base"}
+	if len(order) != len(want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("got %v, want %v", order, want)
+		}
+	}
+}
+
+func TestRetryMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		attempts  int
+		failures  int
+		wantCalls int
+		shouldErr bool
+	}{
+		{"first try", 3, 0, 1, false},
+		{"second try", 3, 1, 2, false},
+		{"gives up", 3, 5, 3, true},
+		{"no retries", 0, 1, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			base := TransportFuncs{
+				QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+					calls++
+					if calls <= tt.failures {
+						return nil, errors.New("dropped")
+					}
+					return []byte(`{}`), nil
+				},
+			}
+
+			tr := Chain(base, RetryMiddleware(tt.attempts, time.Millisecond))
+			_, err := tr.Query(context.Background(), "127.0.0.1:9999", "{}")
+
+			if tt.shouldErr && err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !tt.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("got %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	base := TransportFuncs{
+		SendFunc: func(ctx context.Context, addr string, cmd string) error {
+			return nil
+		},
+	}
+
+	interval := 20 * time.Millisecond
+	tr := Chain(base, RateLimitMiddleware(interval))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := tr.Send(context.Background(), "127.0.0.1:9999", "{}"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Fatalf("three sends took %s, expected at least %s", elapsed, 2*interval)
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	var ops []string
+	base := TransportFuncs{
+		QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+			return []byte(`{}`), nil
+		},
+		SendFunc: func(ctx context.Context, addr string, cmd string) error {
+			return errors.New("udp error")
+		},
+	}
+
+	tr := Chain(base, MetricsMiddleware(func(op string, addr string, elapsed time.Duration, err error) {
+		ops = append(ops, op)
+	}))
+
+	md := &Device{Transport: tr}
+	if _, err := md.SendRawCommandCtx(context.Background(), CmdGetSysinfo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := md.RebootCtx(context.Background()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if len(ops) != 2 || ops[0] != "query" || ops[1] != "send" {
+		t.Fatalf("got ops %v", ops)
+	}
+}
@@ -0,0 +1,97 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// KeyType is a network's security as reported by get_scaninfo and get_stainfo
+type KeyType int
+
+const (
+	KeyOpen KeyType = 0
+	KeyWEP  KeyType = 1
+	KeyWPA  KeyType = 2
+	KeyWPA2 KeyType = 3
+
+	// KeyDefault is sent when the network isn't in the scan, it is what SetWIFI always sent before scanning
+	KeyDefault KeyType = 4
+)
+
+func (k KeyType) String() string {
+	switch k {
+	case KeyOpen:
+		return "Open"
+	case KeyWEP:
+		return "WEP"
+	case KeyWPA:
+		return "WPA"
+	case KeyWPA2:
+		return "WPA2"
+	}
+	return fmt.Sprintf("Unknown(%d)", int(k))
+}
+
+// AccessPoint is a network the device can see
+type AccessPoint struct {
+	SSID    string  `json:"ssid"`
+	KeyType KeyType `json:"key_type"`
+	RSSI    int     `json:"rssi,omitempty"`
+}
+
+// ScanInfo is the result of get_scaninfo
+type ScanInfo struct {
+	List []AccessPoint `json:"ap_list"`
+	KasaErr
+}
+
+// Find returns the strongest access point with the given SSID
+func (s *ScanInfo) Find(ssid string) (AccessPoint, bool) {
+	var best AccessPoint
+	found := false
+	for _, ap := range s.List {
+		if ap.SSID == ssid && (!found || ap.RSSI > best.RSSI) {
+			best, found = ap, true
+		}
+	}
+	return best, found
+}
+
+// ScanWIFI has the device survey the networks it can see, strongest first. The scan takes a few seconds.
+func (d *Device) ScanWIFI() (*ScanInfo, error) {
+	return d.ScanWIFICtx(context.Background())
+}
+
+func (d *Device) ScanWIFICtx(ctx context.Context) (*ScanInfo, error) {
+	var s ScanInfo
+	if err := d.call(ctx, NewRequest().Add("netif", "get_scaninfo", Params{"refresh": 1}), &s); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(s.List, func(i, j int) bool {
+		return s.List[i].RSSI > s.List[j].RSSI
+	})
+	return &s, nil
+}
+
+// SetWIFIKeyType configures the WiFi station info with an explicit key type, key may be empty for open networks
+func (d *Device) SetWIFIKeyType(ssid string, key string, kt KeyType) (*SetStaInfo, error) {
+	return d.SetWIFIKeyTypeCtx(context.Background(), ssid, key, kt)
+}
+
+func (d *Device) SetWIFIKeyTypeCtx(ctx context.Context, ssid string, key string, kt KeyType) (*SetStaInfo, error) {
+	if ssid == "" {
+		return nil, fmt.Errorf("no ssid specified")
+	}
+	if key == "" && kt != KeyOpen {
+		return nil, fmt.Errorf("no key specified")
+	}
+
+	r := NewRequest().Add("netif", "set_stainfo", Params{"ssid": ssid, "password": key, "key_type": int(kt)})
+
+	var set SetStaInfo
+	if err := d.call(ctx, r, &set); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
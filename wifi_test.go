@@ -0,0 +1,107 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSetWIFIKeyType(t *testing.T) {
+	scan := `{"netif":{"get_scaninfo":{"ap_list":[{"ssid":"guest","key_type":0,"rssi":-70},{"ssid":"home","key_type":3,"rssi":-48},{"ssid":"attic","key_type":2,"rssi":-81}],"err_code":0}}}`
+
+	tests := []struct {
+		name      string
+		call      func(d *Device) error
+		want      string
+		shouldErr bool
+	}{
+		{"from scan", func(d *Device) error { _, err := d.SetWIFICtx(context.Background(), "attic", "pw"); return err },
+			`{"netif":{"set_stainfo":{"key_type":2,"password":"pw","ssid":"attic"}}}`, false},
+		{"not in scan", func(d *Device) error { _, err := d.SetWIFICtx(context.Background(), "hidden", "pw"); return err },
+			`{"netif":{"set_stainfo":{"key_type":4,"password":"pw","ssid":"hidden"}}}`, false},
+		{"open from scan", func(d *Device) error { _, err := d.SetWIFICtx(context.Background(), "guest", ""); return err },
+			`{"netif":{"set_stainfo":{"key_type":0,"password":"","ssid":"guest"}}}`, false},
+		{"explicit", func(d *Device) error {
+			_, err := d.SetWIFIKeyTypeCtx(context.Background(), "home", "pw", KeyWPA2)
+			return err
+		}, `{"netif":{"set_stainfo":{"key_type":3,"password":"pw","ssid":"home"}}}`, false},
+		{"missing key", func(d *Device) error { _, err := d.SetWIFICtx(context.Background(), "home", ""); return err }, "", true},
+		{"missing ssid", func(d *Device) error { _, err := d.SetWIFICtx(context.Background(), "", "pw"); return err }, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent string
+			d := &Device{
+				Transport: TransportFuncs{
+					QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+						if strings.Contains(cmd, "get_scaninfo") {
+							return []byte(scan), nil
+						}
+						sent = cmd
+						return []byte(`{"netif":{"set_stainfo":{"err_code":0}}}`), nil
+					},
+				},
+			}
+
+			err := tt.call(d)
+			if tt.shouldErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sent != tt.want {
+				t.Fatalf("sent %s, want %s", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanWIFI(t *testing.T) {
+	d := mockQuery(`{"netif":{"get_scaninfo":{"ap_list":[{"ssid":"attic","key_type":2,"rssi":-81},{"ssid":"home","key_type":3,"rssi":-48}],"err_code":0}}}`)
+
+	s, err := d.ScanWIFICtx(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.List) != 2 || s.List[0].SSID != "home" || s.List[0].KeyType != KeyWPA2 {
+		t.Fatalf("got %+v", s.List)
+	}
+	if _, ok := s.Find("neighbor"); ok {
+		t.Fatal("found a network that isn't in the scan")
+	}
+
+	// a list that did not come from ScanWIFI is not sorted
+	u := ScanInfo{List: []AccessPoint{{SSID: "home", KeyType: KeyWPA, RSSI: -80}, {SSID: "home", KeyType: KeyWPA2, RSSI: -50}}}
+	if ap, ok := u.Find("home"); !ok || ap.RSSI != -50 {
+		t.Fatalf("got %+v, want the strongest", ap)
+	}
+}
+
+func TestSetWIFIScanError(t *testing.T) {
+	var sent []string
+	d := &Device{
+		Transport: TransportFuncs{
+			QueryFunc: func(ctx context.Context, addr string, cmd string) ([]byte, error) {
+				if strings.Contains(cmd, "get_scaninfo") {
+					return nil, context.DeadlineExceeded
+				}
+				sent = append(sent, cmd)
+				return []byte(`{"netif":{"set_stainfo":{"err_code":0}}}`), nil
+			},
+		},
+	}
+
+	_, err := d.SetWIFICtx(context.Background(), "home", "pw")
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("got %v, want the scan's timeout", err)
+	}
+	if len(sent) != 0 {
+		t.Fatalf("sent %v after the scan failed", sent)
+	}
+}